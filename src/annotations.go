@@ -0,0 +1,38 @@
+package slapperx
+
+import (
+	"sync"
+	"time"
+)
+
+// Annotation is a note taken during the run, e.g. "deploy started"
+type Annotation struct {
+	Time time.Time
+	Text string
+}
+
+// Annotations keeps the notes of the run in the order they were taken. They are not affected by a reset.
+type Annotations struct {
+	mu   sync.Mutex
+	list []Annotation
+}
+
+func NewAnnotations() *Annotations {
+	return &Annotations{}
+}
+
+// Add takes a note with the current time
+func (a *Annotations) Add(text string) Annotation {
+	annotation := Annotation{Time: time.Now(), Text: text}
+	a.mu.Lock()
+	a.list = append(a.list, annotation)
+	a.mu.Unlock()
+	return annotation
+}
+
+// List returns a copy of all notes
+func (a *Annotations) List() []Annotation {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]Annotation(nil), a.list...)
+}
@@ -0,0 +1,208 @@
+package chart
+
+import (
+	"math"
+	"strings"
+)
+
+// dotBits are the bits of the braille dots by column and row inside a character
+var dotBits = [2][4]rune{
+	{0x01, 0x02, 0x04, 0x40},
+	{0x08, 0x10, 0x20, 0x80},
+}
+
+const brailleBlank = 0x2800
+
+// Canvas is a plot area of braille characters. Every character contains 2x4 dots.
+// The color of a character is the one of the last dot set in it.
+type Canvas struct {
+	width  int // in characters
+	height int
+	dots   [][]rune
+	colors [][]string
+}
+
+func NewCanvas(width, height int) *Canvas {
+	c := &Canvas{
+		width:  max(width, 0),
+		height: max(height, 0),
+	}
+	c.dots = make([][]rune, c.height)
+	c.colors = make([][]string, c.height)
+	for row := range c.dots {
+		c.dots[row] = make([]rune, c.width)
+		c.colors[row] = make([]string, c.width)
+	}
+	return c
+}
+
+// Width returns the number of dots in x direction
+func (c *Canvas) Width() int {
+	return 2 * c.width
+}
+
+// Height returns the number of dots in y direction
+func (c *Canvas) Height() int {
+	return 4 * c.height
+}
+
+// Set sets the dot at x, y. y=0 is the bottom line. Dots outside of the canvas are ignored.
+func (c *Canvas) Set(x, y int, color string) {
+	if x < 0 || y < 0 || x >= c.Width() || y >= c.Height() {
+		return
+	}
+	y = c.Height() - 1 - y
+	row, col := y/4, x/2
+	c.dots[row][col] |= dotBits[x%2][y%4]
+	c.colors[row][col] = color
+}
+
+// Line draws a line between two dots
+func (c *Canvas) Line(x0, y0, x1, y1 int, color string) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := sign(x1-x0), sign(y1-y0)
+	e := dx + dy
+	for {
+		c.Set(x0, y0, color)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		if 2*e >= dy {
+			e += dy
+			x0 += sx
+		}
+		if 2*e <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+// VLine draws a dotted vertical line over the full height at x
+func (c *Canvas) VLine(x int, color string) {
+	for y := 0; y < c.Height(); y += 2 {
+		c.Set(x, y, color)
+	}
+}
+
+// Plot draws the values as connected line, one value per dot column, scaled from 0 to maxValue.
+// NaN values leave a gap.
+func (c *Canvas) Plot(values []float64, maxValue float64, color string) {
+	if maxValue <= 0 || c.Height() == 0 {
+		return
+	}
+	scale := float64(c.Height()-1) / maxValue
+	lastX, lastY := -1, 0
+	for x, v := range values {
+		if math.IsNaN(v) {
+			lastX = -1
+			continue
+		}
+		y := int(math.Round(min(v, maxValue) * scale))
+		if lastX >= 0 {
+			c.Line(lastX, lastY, x, y, color)
+		} else {
+			c.Set(x, y, color)
+		}
+		lastX, lastY = x, y
+	}
+}
+
+// Row returns the characters of a row counted from the top with ANSI colors
+func (c *Canvas) Row(row int) string {
+	var sb strings.Builder
+	color := ""
+	for col, dots := range c.dots[row] {
+		if dots == 0 {
+			sb.WriteByte(' ')
+			continue
+		}
+		if c.colors[row][col] != color {
+			color = c.colors[row][col]
+			sb.WriteString("\033[0m")
+			sb.WriteString(color)
+		}
+		sb.WriteRune(brailleBlank + dots)
+	}
+	if color != "" {
+		sb.WriteString("\033[0m")
+	}
+	return sb.String()
+}
+
+// Downsample reduces the values to n values by averaging neighbours. NaN values are ignored.
+// If there are fewer values than n, they are returned unchanged.
+func Downsample(values []float64, n int) []float64 {
+	if len(values) <= n {
+		return values
+	}
+	result := make([]float64, n)
+	for i := range result {
+		from, to := i*len(values)/n, (i+1)*len(values)/n
+		sum, count := 0., 0
+		for _, v := range values[from:to] {
+			if !math.IsNaN(v) {
+				sum += v
+				count++
+			}
+		}
+		result[i] = math.NaN()
+		if count > 0 {
+			result[i] = sum / float64(count)
+		}
+	}
+	return result
+}
+
+// DownsampleIndex returns the index of the value i of length values after Downsample to n values
+func DownsampleIndex(i, length, n int) int {
+	if length <= n {
+		return i
+	}
+	return i * n / length
+}
+
+// NiceMax rounds the maximum of an axis up to 1, 2 or 5 times a power of ten
+func NiceMax(v float64) float64 {
+	if v <= 0 || math.IsNaN(v) {
+		return 1
+	}
+	magnitude := math.Pow(10, math.Floor(math.Log10(v)))
+	for _, m := range []float64{1, 2, 5} {
+		if m*magnitude >= v {
+			return m * magnitude
+		}
+	}
+	return 10 * magnitude
+}
+
+// NiceMin rounds the minimum of an axis down to 1, 2 or 5 times a power of ten
+func NiceMin(v float64) float64 {
+	if v <= 0 || math.IsNaN(v) {
+		return 0
+	}
+	magnitude := math.Pow(10, math.Floor(math.Log10(v)))
+	for _, m := range []float64{5, 2, 1} {
+		if m*magnitude <= v {
+			return m * magnitude
+		}
+	}
+	return magnitude
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func sign(x int) int {
+	switch {
+	case x < 0:
+		return -1
+	case x > 0:
+		return 1
+	}
+	return 0
+}
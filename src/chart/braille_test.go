@@ -0,0 +1,73 @@
+package chart
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCanvasSet(t *testing.T) {
+	c := NewCanvas(2, 1)
+	c.Set(0, 3, "") // top left
+	c.Set(1, 0, "") // bottom right of the first character
+	c.Set(4, 0, "") // outside
+	if row := c.Row(0); row != "⢁ " {
+		t.Errorf("Unexpected row %q", row)
+	}
+}
+
+func TestCanvasPlot(t *testing.T) {
+	c := NewCanvas(2, 1)
+	c.Plot([]float64{0, 1, math.NaN(), 3}, 3, "")
+	// the dots of the first two values are connected, the last one stands alone
+	if row := c.Row(0); row != "⡠⠈" {
+		t.Errorf("Unexpected row %q", row)
+	}
+
+	c = NewCanvas(1, 1)
+	c.Plot([]float64{0, 1}, 1, "\033[31m")
+	if row := c.Row(0); row != "\033[0m\033[31m⡜\033[0m" {
+		t.Errorf("Unexpected colored row %q", row)
+	}
+}
+
+func TestCanvasVLine(t *testing.T) {
+	c := NewCanvas(2, 2)
+	c.VLine(1, "")
+	if c.Row(0) != "⢐ " || c.Row(1) != "⢐ " {
+		t.Errorf("Unexpected rows %q %q", c.Row(0), c.Row(1))
+	}
+}
+
+func TestDownsample(t *testing.T) {
+	values := Downsample([]float64{1, 3, math.NaN(), math.NaN(), 5, math.NaN()}, 3)
+	if len(values) != 3 || values[0] != 2 || !math.IsNaN(values[1]) || values[2] != 5 {
+		t.Errorf("Unexpected values %v", values)
+	}
+	if values := Downsample([]float64{1, 2}, 3); len(values) != 2 {
+		t.Errorf("Expected values unchanged, got %v", values)
+	}
+	if i := DownsampleIndex(4, 6, 3); i != 2 {
+		t.Errorf("Expected index 2, got %d", i)
+	}
+	if i := DownsampleIndex(1, 2, 3); i != 1 {
+		t.Errorf("Expected index unchanged, got %d", i)
+	}
+}
+
+func TestNiceMax(t *testing.T) {
+	tests := map[float64]float64{0: 1, 0.3: 0.5, 1: 1, 1.1: 2, 42: 50, 510: 1000}
+	for v, expected := range tests {
+		if got := NiceMax(v); math.Abs(got-expected) > 1e-9 {
+			t.Errorf("NiceMax(%v) = %v, expected %v", v, got, expected)
+		}
+	}
+}
+
+func TestNiceMin(t *testing.T) {
+	tests := map[float64]float64{0: 0, 0.3: 0.2, 1: 1, 1.9: 1, 42: 20, 510: 500}
+	for v, expected := range tests {
+		if got := NiceMin(v); math.Abs(got-expected) > 1e-9 {
+			t.Errorf("NiceMin(%v) = %v, expected %v", v, got, expected)
+		}
+	}
+}
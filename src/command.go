@@ -0,0 +1,88 @@
+package slapperx
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// commandUsage lists the commands of the prompt
+const commandUsage = "usage: rate <rps> | workers <n> | timeout <duration> | profile <name> | note <text> | " +
+	"enable|disable|toggle <number|name|@tag|all> | filter <all|2xx|3xx|4xx|5xx|error|status>"
+
+// Execute runs a command of the prompt, e.g. "rate 750", "workers 200", "timeout 2s", "note deploy started"
+// or "disable @checkout", and returns a message describing the result
+func (c *Controller) Execute(command string) (string, error) {
+	name, arg, _ := strings.Cut(strings.TrimSpace(command), " ")
+	arg = strings.TrimSpace(arg)
+	if name == "" {
+		return "", nil
+	}
+	if arg == "" {
+		return "", errors.New(commandUsage)
+	}
+	switch name {
+	case "note":
+		if err := c.Annotate(arg); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("annotation %q added", arg), nil
+	case "enable", "disable":
+		n, err := c.EnableRequests(arg, name == "enable")
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%sd %d request(s)", name, n), nil
+	case "toggle":
+		enabled, n, err := c.ToggleRequests(arg)
+		if err != nil {
+			return "", err
+		}
+		if enabled {
+			return fmt.Sprintf("enabled %d request(s)", n), nil
+		}
+		return fmt.Sprintf("disabled %d request(s)", n), nil
+	case "rate":
+		rate, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid rate %q", arg)
+		}
+		if err := c.SetRate(rate); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("rate set to %g RPS", rate), nil
+	case "workers":
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 1 {
+			return "", fmt.Errorf("invalid number of workers %q", arg)
+		}
+		c.SetWorkers(n)
+		return fmt.Sprintf("workers set to %d", n), nil
+	case "timeout":
+		timeout, err := time.ParseDuration(arg)
+		if err != nil || timeout < 0 {
+			return "", fmt.Errorf("invalid timeout %q", arg)
+		}
+		c.SetTimeout(timeout)
+		return fmt.Sprintf("timeout set to %s", timeout), nil
+	case "filter":
+		if ui == nil {
+			return "", errors.New("no histogram without terminal")
+		}
+		filter, err := parseStatusFilter(arg)
+		if err != nil {
+			return "", err
+		}
+		ui.SetFilter(filter)
+		return fmt.Sprintf("histogram filter set to %s", filter), nil
+	case "profile":
+		if err := c.SwitchProfile(arg); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("switching to profile %s", arg), nil
+	default:
+		return "", fmt.Errorf("unknown command %q", name)
+	}
+}
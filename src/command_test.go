@@ -0,0 +1,45 @@
+package slapperx
+
+import "testing"
+
+func TestExecute(t *testing.T) {
+	tests := []struct {
+		command string
+		message string
+		fails   bool
+		rate    float64
+	}{
+		{"", "", false, 50},
+		{"rate 750", "rate set to 750 RPS", false, 750},
+		{"  rate   12.5 ", "rate set to 12.5 RPS", false, 12.5},
+		{"rate", "", true, 50},
+		{"rate abc", "", true, 50},
+		{"rate 0", "", true, 50},
+		{"rate -5", "", true, 50},
+		{"rate 1e300", "", true, 50},
+		{"workers 0", "", true, 50},
+		{"workers -3", "", true, 50},
+		{"workers many", "", true, 50},
+		{"workers", "", true, 50},
+		{"timeout -1s", "", true, 50},
+		{"timeout 5", "", true, 50},
+		{"timeout", "", true, 50},
+		{"speed 10", "", true, 50},
+		{"profile", "", true, 50},
+		{"profile unknown", "", true, 50},
+	}
+	for _, test := range tests {
+		c := NewController(NewRamUpController(0, 50), nil, nil, nil)
+		message, err := c.Execute(test.command)
+		if (err != nil) != test.fails {
+			t.Errorf("%q: unexpected error %v", test.command, err)
+			continue
+		}
+		if message != test.message {
+			t.Errorf("%q: expected message %q, got %q", test.command, test.message, message)
+		}
+		if rate := c.rampUpController.TargetRate(); rate != test.rate {
+			t.Errorf("%q: expected rate %g, got %g", test.command, test.rate, rate)
+		}
+	}
+}
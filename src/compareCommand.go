@@ -0,0 +1,104 @@
+package slapperx
+
+import (
+	"flag"
+	"fmt"
+	"github.com/s-macke/slapperx/src/report"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// fraction is a flag value given as fraction (0.1) or as percentage (10%)
+type fraction float64
+
+func (f *fraction) String() string {
+	return strconv.FormatFloat(float64(*f)*100, 'g', -1, 64) + "%"
+}
+
+func (f *fraction) Set(value string) error {
+	percent := strings.HasSuffix(value, "%")
+	v, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+	if err != nil {
+		return err
+	}
+	if percent {
+		v /= 100
+	}
+	*f = fraction(v)
+	return nil
+}
+
+// CompareMain compares two run summaries and returns the exit code, 1 if the candidate regressed
+func CompareMain(args []string) int {
+	flags := flag.NewFlagSet("compare", flag.ContinueOnError)
+	latency := fraction(0.1)
+	throughput := fraction(0.1)
+	errorRate := fraction(0.01)
+	flags.Var(&latency, "latency-tolerance", "Allowed increase of the latency percentiles")
+	flags.Var(&throughput, "throughput-tolerance", "Allowed decrease of the throughput")
+	flags.Var(&errorRate, "error-tolerance", "Allowed increase of the error rate in percentage points")
+	jsonOutput := flags.Bool("json", false, "Write the comparison as JSON")
+	flags.Usage = func() {
+		_, _ = fmt.Fprintf(flags.Output(), "Usage: slapperx compare [options] baseline.json candidate.json\n\nOptions:\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return 2
+	}
+
+	baseline, err := readSummary(flags.Arg(0))
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Failed to read baseline: %v\n", err)
+		return 2
+	}
+	candidate, err := readSummary(flags.Arg(1))
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Failed to read candidate: %v\n", err)
+		return 2
+	}
+
+	comparison := report.Compare(baseline, candidate, report.Tolerance{
+		Latency:    float64(latency),
+		Throughput: float64(throughput),
+		ErrorRate:  float64(errorRate),
+	})
+	if *jsonOutput {
+		err = report.WriteComparisonJSON(os.Stdout, comparison)
+	} else {
+		err = report.WriteComparisonText(os.Stdout, comparison)
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Failed to write comparison: %v\n", err)
+		return 2
+	}
+	if comparison.Regressed {
+		return 1
+	}
+	return 0
+}
+
+func readSummary(path string) (*report.RunSummary, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return report.ReadSummary(file)
+}
+
+func writeSummary(path string, summary *report.RunSummary) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = report.WriteSummary(file, summary)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,227 @@
+package control
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/url"
+
+	"github.com/s-macke/slapperx/src/report"
+)
+
+// LoadControls are the operations which change the load. They are offered by the control API and the web UI.
+type LoadControls interface {
+	Status() Status
+	SetRate(rate float64) error
+	ChangeRate(delta float64) error
+	Pause()
+	Resume()
+	Reset()
+}
+
+// Controls are the operations offered by the control API
+type Controls interface {
+	LoadControls
+	Stats() *report.RunSummary
+	SwitchProfile(name string) error
+	Annotate(text string) error
+	Requests() []Request
+	EnableRequests(target string, enabled bool) (int, error)
+	Stop()
+}
+
+// Request is a request of the HTTP file and whether it is sent
+type Request struct {
+	Number  int      `json:"number"` // position in the HTTP file, starting at 1
+	Name    string   `json:"name"`
+	Tags    []string `json:"tags,omitempty"`
+	Enabled bool     `json:"enabled"`
+}
+
+// Status is the current state of the run
+type Status struct {
+	ElapsedS   float64  `json:"elapsed_s"`
+	SetRate    float64  `json:"set_rate"`    // current rate, also during a ramp
+	TargetRate float64  `json:"target_rate"` // rate at the end of the ramp
+	Paused     bool     `json:"paused"`
+	Profile    string   `json:"profile,omitempty"` // last switched profile
+	Profiles   []string `json:"profiles"`
+	InFlight   int64    `json:"in_flight"`
+	Sent       int64    `json:"sent"` // since the start or the last reset
+	Responses  int64    `json:"responses"`
+}
+
+// Server serves the JSON control API
+type Server struct {
+	controls Controls
+}
+
+func NewServer(controls Controls) *Server {
+	return &Server{controls: controls}
+}
+
+// Handler returns the routes of the control API. Every POST returns the status after the change,
+// except POST /requests, which returns the requests.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, s.controls.Status())
+	})
+	mux.HandleFunc("GET /stats", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, s.controls.Stats())
+	})
+	HandleLoad(mux, s.controls)
+	mux.HandleFunc("POST /profile", sameOrigin(s.profile))
+	mux.HandleFunc("POST /annotations", sameOrigin(s.annotate))
+	mux.HandleFunc("GET /requests", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, s.controls.Requests())
+	})
+	mux.HandleFunc("POST /requests", sameOrigin(s.enableRequests))
+	mux.HandleFunc("POST /stop", sameOrigin(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, s.controls.Status())
+		// stopping ends the program, so answer first
+		go s.controls.Stop()
+	}))
+	return mux
+}
+
+// HandleLoad registers the routes which change the load: POST /rate, /pause, /resume and /reset.
+// They return the status after the change.
+func HandleLoad(mux *http.ServeMux, controls LoadControls) {
+	mux.HandleFunc("POST /rate", sameOrigin(rateHandler(controls)))
+	mux.HandleFunc("POST /pause", sameOrigin(actionHandler(controls, controls.Pause)))
+	mux.HandleFunc("POST /resume", sameOrigin(actionHandler(controls, controls.Resume)))
+	mux.HandleFunc("POST /reset", sameOrigin(actionHandler(controls, controls.Reset)))
+}
+
+// sameOrigin rejects requests sent by the pages of other sites, so that a page open in the
+// operator's browser cannot change the load. Browsers send an Origin header with every POST, curl does not.
+func sameOrigin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if site := r.Header.Get("Sec-Fetch-Site"); site != "" && site != "same-origin" && site != "none" {
+			writeError(w, http.StatusForbidden, "cross-origin request")
+			return
+		}
+		if origin := r.Header.Get("Origin"); origin != "" {
+			if u, err := url.Parse(origin); err != nil || u.Host != r.Host {
+				writeError(w, http.StatusForbidden, "cross-origin request")
+				return
+			}
+		}
+		next(w, r)
+	}
+}
+
+// Serve serves the control API in the background until the listener is closed
+func (s *Server) Serve(listener net.Listener) {
+	go func() {
+		_ = http.Serve(listener, s.Handler())
+	}()
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, errorResponse{Error: msg})
+}
+
+// rateHandler sets the rate to {"rate": 500} or changes it by {"delta": -10}
+func rateHandler(controls LoadControls) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Rate  *float64 `json:"rate"`
+			Delta *float64 `json:"delta"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+			return
+		}
+		var err error
+		switch {
+		case body.Rate != nil && body.Delta != nil:
+			writeError(w, http.StatusBadRequest, "either rate or delta expected")
+			return
+		case body.Rate != nil:
+			err = controls.SetRate(*body.Rate)
+		case body.Delta != nil:
+			err = controls.ChangeRate(*body.Delta)
+		default:
+			writeError(w, http.StatusBadRequest, "rate or delta expected")
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, controls.Status())
+	}
+}
+
+// profile switches to the profile {"name": "peak"}
+func (s *Server) profile(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	if err := s.controls.SwitchProfile(body.Name); err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, s.controls.Status())
+}
+
+// annotate adds the annotation {"text": "deploy started"} to the log file and the report
+func (s *Server) annotate(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Text string `json:"text"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	if err := s.controls.Annotate(body.Text); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, s.controls.Status())
+}
+
+// enableRequests enables or disables requests {"target": "@checkout", "enabled": false} and returns all requests.
+// The target is the number or name of a request, @tag or all.
+func (s *Server) enableRequests(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Target  string `json:"target"`
+		Enabled *bool  `json:"enabled"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	if body.Target == "" || body.Enabled == nil {
+		writeError(w, http.StatusBadRequest, "target and enabled expected")
+		return
+	}
+	if _, err := s.controls.EnableRequests(body.Target, *body.Enabled); err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, s.controls.Requests())
+}
+
+func actionHandler(controls LoadControls, f func()) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		f()
+		writeJSON(w, http.StatusOK, controls.Status())
+	}
+}
@@ -0,0 +1,258 @@
+package control
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/s-macke/slapperx/src/report"
+)
+
+type testControls struct {
+	mu          sync.Mutex
+	status      Status
+	resets      int
+	annotations []string
+	requests    []Request
+	stopped     chan struct{}
+}
+
+func newTestControls() *testControls {
+	return &testControls{
+		status:   Status{SetRate: 50, Profiles: []string{"peak"}},
+		requests: []Request{{Number: 1, Name: "home", Enabled: true}, {Number: 2, Name: "cart", Tags: []string{"checkout"}, Enabled: true}},
+		stopped:  make(chan struct{}),
+	}
+}
+
+func (c *testControls) Status() Status {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.status
+}
+
+func (c *testControls) Stats() *report.RunSummary {
+	return &report.RunSummary{Version: report.SummaryVersion, Overall: report.EndpointSummary{Requests: 42}}
+}
+
+func (c *testControls) SetRate(rate float64) error {
+	if rate <= 0 || rate > 1000 {
+		return errors.New("invalid rate")
+	}
+	c.mu.Lock()
+	c.status.SetRate = rate
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *testControls) ChangeRate(delta float64) error {
+	if delta > 1000 {
+		return errors.New("invalid rate change")
+	}
+	c.mu.Lock()
+	c.status.SetRate += delta
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *testControls) Pause() {
+	c.mu.Lock()
+	c.status.Paused = true
+	c.mu.Unlock()
+}
+
+func (c *testControls) Resume() {
+	c.mu.Lock()
+	c.status.Paused = false
+	c.mu.Unlock()
+}
+
+func (c *testControls) Reset() {
+	c.mu.Lock()
+	c.resets++
+	c.mu.Unlock()
+}
+
+func (c *testControls) SwitchProfile(name string) error {
+	if name != "peak" {
+		return errors.New("unknown profile")
+	}
+	c.mu.Lock()
+	c.status.Profile = name
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *testControls) Annotate(text string) error {
+	if text == "" {
+		return errors.New("empty annotation")
+	}
+	c.mu.Lock()
+	c.annotations = append(c.annotations, text)
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *testControls) Requests() []Request {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Request(nil), c.requests...)
+}
+
+func (c *testControls) EnableRequests(target string, enabled bool) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for i, r := range c.requests {
+		if r.Name == target || (len(r.Tags) > 0 && "@"+r.Tags[0] == target) {
+			c.requests[i].Enabled = enabled
+			n++
+		}
+	}
+	if n == 0 {
+		return 0, errors.New("no request")
+	}
+	return n, nil
+}
+
+func (c *testControls) Stop() {
+	close(c.stopped)
+}
+
+func request(t *testing.T, method, url, body string, v any) int {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	defer resp.Body.Close()
+	if v != nil {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestControlAPI(t *testing.T) {
+	controls := newTestControls()
+	server := httptest.NewServer(NewServer(controls).Handler())
+	defer server.Close()
+
+	var status Status
+	request(t, "GET", server.URL+"/status", "", &status)
+	if status.SetRate != 50 || len(status.Profiles) != 1 {
+		t.Errorf("Unexpected status %+v", status)
+	}
+
+	var summary report.RunSummary
+	request(t, "GET", server.URL+"/stats", "", &summary)
+	if summary.Overall.Requests != 42 {
+		t.Errorf("Unexpected stats %+v", summary)
+	}
+
+	request(t, "POST", server.URL+"/rate", `{"rate": 500}`, &status)
+	if status.SetRate != 500 {
+		t.Errorf("Expected rate 500, got %v", status.SetRate)
+	}
+	request(t, "POST", server.URL+"/rate", `{"delta": -100}`, &status)
+	if status.SetRate != 400 {
+		t.Errorf("Expected rate 400, got %v", status.SetRate)
+	}
+	request(t, "POST", server.URL+"/pause", "", &status)
+	if !status.Paused {
+		t.Errorf("Expected paused")
+	}
+	request(t, "POST", server.URL+"/resume", "", &status)
+	if status.Paused {
+		t.Errorf("Expected resumed")
+	}
+	request(t, "POST", server.URL+"/reset", "", nil)
+	if controls.resets != 1 {
+		t.Errorf("Expected one reset, got %d", controls.resets)
+	}
+	request(t, "POST", server.URL+"/profile", `{"name": "peak"}`, &status)
+	if status.Profile != "peak" {
+		t.Errorf("Expected profile peak, got %q", status.Profile)
+	}
+
+	request(t, "POST", server.URL+"/annotations", `{"text": "deploy started"}`, &status)
+	if len(controls.annotations) != 1 || controls.annotations[0] != "deploy started" {
+		t.Errorf("Expected annotation, got %v", controls.annotations)
+	}
+
+	var requests []Request
+	request(t, "GET", server.URL+"/requests", "", &requests)
+	if len(requests) != 2 || !requests[1].Enabled {
+		t.Errorf("Unexpected requests %+v", requests)
+	}
+	request(t, "POST", server.URL+"/requests", `{"target": "@checkout", "enabled": false}`, &requests)
+	if len(requests) != 2 || !requests[0].Enabled || requests[1].Enabled {
+		t.Errorf("Expected the checkout request disabled, got %+v", requests)
+	}
+
+	request(t, "POST", server.URL+"/stop", "", nil)
+	select {
+	case <-controls.stopped:
+	case <-time.After(2 * time.Second):
+		t.Errorf("Expected stop")
+	}
+}
+
+func TestControlAPICrossOrigin(t *testing.T) {
+	controls := newTestControls()
+	server := httptest.NewServer(NewServer(controls).Handler())
+	defer server.Close()
+
+	for _, path := range []string{"/rate", "/pause", "/profile", "/stop"} {
+		req, _ := http.NewRequest("POST", server.URL+path, strings.NewReader(`{"rate": 100, "name": "peak"}`))
+		req.Header.Set("Origin", "http://evil.example")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to send request: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusForbidden {
+			t.Errorf("POST %s: expected status 403 for other origin, got %d", path, resp.StatusCode)
+		}
+	}
+	if status := controls.Status(); status.SetRate != 50 || status.Paused || status.Profile != "" {
+		t.Errorf("Expected cross-origin requests to be ignored, got %+v", status)
+	}
+}
+
+func TestControlAPIErrors(t *testing.T) {
+	server := httptest.NewServer(NewServer(newTestControls()).Handler())
+	defer server.Close()
+
+	tests := []struct {
+		method, path, body string
+		status             int
+	}{
+		{"POST", "/rate", `{"rate": 0}`, http.StatusBadRequest},
+		{"POST", "/rate", `{"rate": 1e300}`, http.StatusBadRequest},
+		{"POST", "/rate", `{"delta": 1e300}`, http.StatusBadRequest},
+		{"POST", "/rate", `{}`, http.StatusBadRequest},
+		{"POST", "/rate", `{"rate": 1, "delta": 1}`, http.StatusBadRequest},
+		{"POST", "/rate", `rate=1`, http.StatusBadRequest},
+		{"POST", "/profile", `{"name": "unknown"}`, http.StatusNotFound},
+		{"POST", "/annotations", `{"text": ""}`, http.StatusBadRequest},
+		{"POST", "/requests", `{"target": "home"}`, http.StatusBadRequest},
+		{"POST", "/requests", `{"target": "unknown", "enabled": true}`, http.StatusNotFound},
+		{"GET", "/pause", "", http.StatusMethodNotAllowed},
+	}
+	for _, test := range tests {
+		if status := request(t, test.method, server.URL+test.path, test.body, nil); status != test.status {
+			t.Errorf("%s %s %s: expected status %d, got %d", test.method, test.path, test.body, test.status, status)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package slapperx
+
+import (
+	"errors"
+	"fmt"
+	"github.com/s-macke/slapperx/src/control"
+	"github.com/s-macke/slapperx/src/report"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Controller changes the running test. It is shared by the keyboard handlers, the web UI and the control API.
+type Controller struct {
+	rampUpController *RampUpController
+	ticker           *Ticker
+	targeter         *Targeter
+	stop             func()
+	start            time.Time
+	profiles         map[string]Profile
+
+	mu      sync.Mutex
+	profile string // last switched profile
+}
+
+func NewController(rampUpController *RampUpController, ticker *Ticker, targeter *Targeter, profiles map[string]Profile) *Controller {
+	return &Controller{
+		rampUpController: rampUpController,
+		ticker:           ticker,
+		targeter:         targeter,
+		start:            time.Now(),
+		profiles:         profiles,
+	}
+}
+
+// ChangeRate changes the rate by a delta amount. The resulting rate is kept within the supported range.
+func (c *Controller) ChangeRate(delta float64) error {
+	if !(math.Abs(delta) <= maxSetRate) {
+		return fmt.Errorf("the rate change must be at most %g", float64(maxSetRate))
+	}
+	c.rampUpController.ChangeRate(delta)
+	return nil
+}
+
+// SetRate sets the rate to an absolute value. During the ramp up it becomes the final rate.
+func (c *Controller) SetRate(rate float64) error {
+	if err := checkRate(rate); err != nil {
+		return err
+	}
+	c.rampUpController.SetRate(rate)
+	return nil
+}
+
+// Rate returns the current set rate
+func (c *Controller) Rate() float64 {
+	return stats.getSetRate()
+}
+
+// ChangeWorkers starts or stops delta workers
+func (c *Controller) ChangeWorkers(delta int) {
+	if delta > 0 {
+		c.targeter.AddWorkers(delta)
+	} else {
+		c.targeter.RemoveWorkers(-delta)
+	}
+}
+
+// SetWorkers sets the number of workers. At least one worker keeps running.
+func (c *Controller) SetWorkers(n int) {
+	c.targeter.SetWorkers(n)
+}
+
+func (c *Controller) Workers() int {
+	return c.targeter.Workers()
+}
+
+// SetTimeout changes the timeout of the following requests. 0 means no limit.
+func (c *Controller) SetTimeout(timeout time.Duration) {
+	c.targeter.client.SetTimeout(timeout)
+}
+
+// ScaleTimeout multiplies the timeout by factor, but keeps it at least at 1ms. No limit stays no limit.
+func (c *Controller) ScaleTimeout(factor float64) {
+	if timeout := c.Timeout(); timeout > 0 {
+		c.SetTimeout(max(time.Duration(float64(timeout)*factor), time.Millisecond))
+	}
+}
+
+func (c *Controller) Timeout() time.Duration {
+	return c.targeter.client.Timeout()
+}
+
+// EnableRequests enables or disables the requests selected by their number, name, @tag or all.
+// It returns the number of selected requests.
+func (c *Controller) EnableRequests(target string, enabled bool) (int, error) {
+	return c.targeter.requests.SetEnabled(target, enabled)
+}
+
+// ToggleRequests disables the selected requests if any of them is enabled and enables them otherwise
+func (c *Controller) ToggleRequests(target string) (bool, int, error) {
+	return c.targeter.requests.Toggle(target)
+}
+
+// Requests returns the state of all requests
+func (c *Controller) Requests() []control.Request {
+	states := c.targeter.requests.List()
+	requests := make([]control.Request, len(states))
+	for i, r := range states {
+		requests[i] = control.Request(r)
+	}
+	return requests
+}
+
+// SwitchProfile ramps the rate to the one of the profile
+func (c *Controller) SwitchProfile(name string) error {
+	profile, ok := c.profiles[name]
+	if !ok {
+		return fmt.Errorf("unknown profile %q", name)
+	}
+	c.rampUpController.Ramp(profile.Rate, profile.RampUp)
+	c.mu.Lock()
+	c.profile = name
+	c.mu.Unlock()
+	return nil
+}
+
+// Pause stops sending new requests. Requests in flight are finished.
+func (c *Controller) Pause() {
+	c.ticker.Pause()
+}
+
+func (c *Controller) Resume() {
+	c.ticker.Resume()
+}
+
+func (c *Controller) IsPaused() bool {
+	return c.ticker.IsPaused()
+}
+
+// TogglePause pauses or resumes sending requests
+func (c *Controller) TogglePause() {
+	if c.IsPaused() {
+		c.Resume()
+	} else {
+		c.Pause()
+	}
+}
+
+// Annotate takes a timestamped note, which is shown in the time series and written to the log file and the report
+func (c *Controller) Annotate(text string) error {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return errors.New("empty annotation")
+	}
+	c.targeter.writeAnnotation(stats.annotations.Add(text))
+	return nil
+}
+
+// Reset resets the statistics
+func (c *Controller) Reset() {
+	stats.reset()
+}
+
+// Stop ends the test
+func (c *Controller) Stop() {
+	if c.stop != nil {
+		c.stop()
+	}
+}
+
+// Status returns the current state of the run for the control API
+func (c *Controller) Status() control.Status {
+	names := make([]string, 0, len(c.profiles))
+	for name := range c.profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	c.mu.Lock()
+	profile := c.profile
+	c.mu.Unlock()
+	return control.Status{
+		ElapsedS:   time.Since(c.start).Seconds(),
+		SetRate:    stats.getSetRate(),
+		TargetRate: c.rampUpController.TargetRate(),
+		Paused:     c.IsPaused(),
+		Profile:    profile,
+		Profiles:   names,
+		InFlight:   stats.getInFlightRequests(),
+		Sent:       stats.requestsSent.Load(),
+		Responses:  stats.responsesReceived.Load(),
+	}
+}
+
+// Stats returns the summary since the start or the last reset
+func (c *Controller) Stats() *report.RunSummary {
+	return stats.summary.Summary()
+}
@@ -0,0 +1,74 @@
+package slapperx
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestControllerRate(t *testing.T) {
+	c := NewController(NewRamUpController(0, 50), nil, nil, nil)
+
+	for _, rate := range []float64{0, -1, math.Inf(1), math.NaN(), 1e300} {
+		if err := c.SetRate(rate); err == nil {
+			t.Errorf("Expected error for rate %g", rate)
+		}
+	}
+	for _, delta := range []float64{math.Inf(1), math.Inf(-1), math.NaN(), 1e300} {
+		if err := c.ChangeRate(delta); err == nil {
+			t.Errorf("Expected error for rate change %g", delta)
+		}
+	}
+	if rate := c.rampUpController.TargetRate(); rate != 50 {
+		t.Errorf("Expected invalid values to keep the rate 50, got %g", rate)
+	}
+
+	tests := []struct {
+		set      float64
+		delta    float64
+		expected float64
+	}{
+		{100, 0, 100},
+		{100, -1000, minSetRate},
+		{maxSetRate, 10, maxSetRate},
+		{1, 0.5, 1.5},
+	}
+	for _, test := range tests {
+		if err := c.SetRate(test.set); err != nil {
+			t.Fatalf("Unexpected error for rate %g: %v", test.set, err)
+		}
+		if err := c.ChangeRate(test.delta); err != nil {
+			t.Fatalf("Unexpected error for rate change %g: %v", test.delta, err)
+		}
+		if rate := c.rampUpController.TargetRate(); rate != test.expected {
+			t.Errorf("Expected rate %g after %g%+g, got %g", test.expected, test.set, test.delta, rate)
+		}
+	}
+}
+
+// TestControllerRateConcurrent changes the rate through the ramp up and the ticker while the status
+// is read like the control API does. Run with -race to check the access to the set rate.
+func TestControllerRateConcurrent(t *testing.T) {
+	ticker := NewTicker(10)
+	ticks := ticker.Start()
+	go func() {
+		for range ticks {
+		}
+	}()
+	defer ticker.Stop()
+	rampUpController := NewRamUpController(0, 10)
+	go rampUpController.startRampUpTimeProcess(ticker.GetRateChanger())
+	c := NewController(rampUpController, ticker, nil, nil)
+	rampUpController.Ramp(100, time.Second)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for rate := 20.; c.Status().SetRate < 20; rate++ {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the set rate to follow the ramp, got %g", c.Rate())
+		}
+		if err := c.SetRate(rate); err != nil {
+			t.Fatalf("Unexpected error for rate %g: %v", rate, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,43 @@
+package slapperx
+
+import (
+	"github.com/s-macke/slapperx/src/web"
+	"time"
+)
+
+// histogramBucketsPerDecade is the resolution of the latency histogram in the web UI
+const histogramBucketsPerDecade = 10
+
+// startDashboard publishes the state of the run to the web UI every second until the server stops
+func startDashboard(server *web.Server, controller *Controller) {
+	interval := stats.metrics.NewInterval()
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		last := time.Now()
+		for {
+			var now time.Time
+			select {
+			case now = <-ticker.C:
+			case <-server.Done():
+				return
+			}
+			summary := interval.Take()
+			total := stats.summary.Summary().Overall
+			server.Publish(web.Sample{
+				Time:          now.UnixMilli(),
+				Rate:          float64(summary.Overall.Requests) / now.Sub(last).Seconds(),
+				SetRate:       stats.getSetRate(),
+				Paused:        controller.IsPaused(),
+				InFlight:      stats.getInFlightRequests(),
+				Requests:      summary.Overall.Requests,
+				Errors:        summary.Overall.Errors,
+				Latency:       summary.Overall.Latency,
+				TotalRequests: total.Requests,
+				TotalErrors:   total.Errors,
+				Histogram:     stats.summary.Histogram(histogramBucketsPerDecade),
+			})
+			last = now
+		}
+	}()
+}
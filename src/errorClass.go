@@ -0,0 +1,49 @@
+package slapperx
+
+import (
+	"errors"
+	"github.com/s-macke/slapperx/src/tracing"
+	"io"
+	"net"
+	"os"
+	"syscall"
+)
+
+// errorClass is the category of a failed request as shown in the UI and written to the log file
+type errorClass string
+
+const (
+	errorClassNone        errorClass = ""
+	errorClassProxy       errorClass = "proxy"
+	errorClassEOF         errorClass = "eof"
+	errorClassConnRefused errorClass = "conn_refused"
+	errorClassTimeout     errorClass = "timeout"
+	errorClassNoSuchHost  errorClass = "no_such_host"
+	errorClassOther       errorClass = "other"
+)
+
+func classifyError(err error) errorClass {
+	var dnsError *net.DNSError
+	switch {
+	case
+		err == nil:
+		return errorClassNone
+	case
+		tracing.IsProxyError(err):
+		return errorClassProxy
+	case
+		errors.Is(err, io.EOF):
+		return errorClassEOF
+	case
+		errors.Is(err, syscall.ECONNREFUSED):
+		return errorClassConnRefused
+	case
+		os.IsTimeout(err):
+		return errorClassTimeout
+	case
+		errors.As(err, &dnsError):
+		return errorClassNoSuchHost
+	default:
+		return errorClassOther
+	}
+}
@@ -0,0 +1,94 @@
+package slapperx
+
+import (
+	"fmt"
+	"github.com/s-macke/slapperx/src/logformat"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxErrorMessages is the number of distinct messages kept, the least recent ones are dropped
+const maxErrorMessages = 100
+
+// ErrorMessage is a distinct error of a request with the number of occurrences
+type ErrorMessage struct {
+	Name    string
+	Message string
+	Count   int64
+	Last    time.Time
+}
+
+// ErrorLog collects the failed requests and error responses by request name and message
+type ErrorLog struct {
+	mu       sync.Mutex
+	messages map[string]*ErrorMessage
+}
+
+func NewErrorLog() *ErrorLog {
+	return &ErrorLog{messages: make(map[string]*ErrorMessage)}
+}
+
+// errorMessage returns the message of a failed request or a response with status >= 400, "" otherwise
+func errorMessage(record *logformat.Record) string {
+	if record.Error != "" {
+		return fmt.Sprintf("[%s] %s", record.ErrorClass, record.Error)
+	}
+	if record.Status >= 400 {
+		return fmt.Sprintf("%d %s", record.Status, http.StatusText(record.Status))
+	}
+	return ""
+}
+
+// Add counts the request if it failed
+func (l *ErrorLog) Add(record *logformat.Record) {
+	message := errorMessage(record)
+	if message == "" {
+		return
+	}
+	key := record.Name + "\x00" + message
+	end := record.Timestamp.Add(time.Duration(record.ElapsedMs * float64(time.Millisecond)))
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	m, ok := l.messages[key]
+	if !ok {
+		if len(l.messages) >= maxErrorMessages {
+			l.dropOldest()
+		}
+		m = &ErrorMessage{Name: record.Name, Message: message}
+		l.messages[key] = m
+	}
+	m.Count++
+	m.Last = end
+}
+
+func (l *ErrorLog) dropOldest() {
+	var oldestKey string
+	var oldest time.Time
+	for key, m := range l.messages {
+		if oldestKey == "" || m.Last.Before(oldest) {
+			oldestKey, oldest = key, m.Last
+		}
+	}
+	delete(l.messages, oldestKey)
+}
+
+// Recent returns the messages, the most recent first
+func (l *ErrorLog) Recent() []ErrorMessage {
+	l.mu.Lock()
+	messages := make([]ErrorMessage, 0, len(l.messages))
+	for _, m := range l.messages {
+		messages = append(messages, *m)
+	}
+	l.mu.Unlock()
+	sort.Slice(messages, func(i, j int) bool { return messages[i].Last.After(messages[j].Last) })
+	return messages
+}
+
+func (l *ErrorLog) Reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	clear(l.messages)
+}
@@ -2,10 +2,24 @@
 
 import (
 	"flag"
+	"fmt"
 	"os"
+	"strings"
 	"time"
 )
 
+// stringList is a flag which can be given multiple times
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ", ")
+}
+
+func (s *stringList) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
 type Config struct {
 	Workers   uint
 	Timeout   time.Duration
@@ -16,7 +30,38 @@ type Config struct {
 	MaxY      time.Duration
 	RampUp    time.Duration
 	LogFile   string
+	LogFormat string
 	Verbose   bool
+	HTTP3     bool
+
+	KeepAlive       bool
+	MaxConnsPerHost int
+	MaxIdle         int
+	IdleTimeout     time.Duration
+	Connections     int
+	Proxy           string
+	SourceIPs       string
+	Resolve         []string
+	DNSCacheTTL     time.Duration
+	UnixSocket      string
+	HTMLReport      string
+	Summary         string
+	Duration        time.Duration
+	Thresholds      []string
+	ThresholdAbort  bool
+	JUnit           string
+	MetricsAddr     string
+	StatsD          string
+	Influx          string
+	OTLP            string
+	PushInterval    time.Duration
+	Web             string
+	ControlAddr     string
+	Profiles        []string
+	Scale           string
+	BucketEdges     string
+	Headless        bool
+	StatusInterval  time.Duration
 }
 
 func ParseFlags() *Config {
@@ -27,9 +72,48 @@ func ParseFlags() *Config {
 	rate := flag.Float64("rate", 50.0, "Requests per second.")
 	minY := flag.Duration("minY", 1, "Min on Y axis (default 1ms)")
 	maxY := flag.Duration("maxY", 100*time.Millisecond, "Max on Y axis")
+	scale := flag.String("scale", "log", "Scale of the Y axis: log, linear or custom")
+	bucketEdges := flag.String("bucket-edges", "", "Comma separated upper bounds of the histogram buckets for the custom scale, e.g. 1ms,5ms,10ms,50ms")
 	rampUp := flag.Duration("rampup", 0*time.Second, "Ramp up time")
-	logFile := flag.String("log", "", "Output result as csv file")
+	duration := flag.Duration("duration", 0, "Stop the run after this time (0 = run until quit)")
+	var thresholds stringList
+	flag.Var(&thresholds, "threshold", "Fail the run if the expression like p99<300ms, errors<0.1% or rate>=0.95*set is violated. Can be given multiple times")
+	thresholdAbort := flag.Bool("threshold-abort", false, "Stop the run as soon as a threshold can no longer be met")
+	webAddr := flag.String("web", "", "Serve the web UI at this address, e.g. localhost:8089")
+	controlAddr := flag.String("control-addr", "", "Serve the JSON control API at this address, e.g. localhost:8090")
+	var profiles stringList
+	flag.Var(&profiles, "profile", "Load profile name=rate[,rampup], e.g. peak=500,30s, which can be switched to via the control API. Can be given multiple times")
+	metricsAddr := flag.String("metrics-addr", "", "Serve Prometheus metrics on /metrics at this address, e.g. :9090")
+	statsD := flag.String("statsd", "", "Push metrics to this StatsD server, e.g. localhost:8125")
+	influx := flag.String("influx", "", "Push metrics to this InfluxDB write URL. The token is taken from INFLUX_TOKEN")
+	otlp := flag.String("otlp", "", "Push metrics to this OTLP/HTTP endpoint, e.g. http://localhost:4318/v1/metrics")
+	pushInterval := flag.Duration("push-interval", 10*time.Second, "Interval for pushing metrics")
+	junit := flag.String("junit", "", "Write the threshold results as JUnit XML to this file")
+	logFile := flag.String("log", "", "Write every request to this log file")
+	logFormat := flag.String("log-format", "csv", "Format of the log file: csv or jsonl")
+	summary := flag.String("summary", "", "Write a machine readable summary for the compare command to this file at the end of the run")
+	htmlReport := flag.String("html-report", "", "Write an HTML report with charts to this file at the end of the run")
 	verbose := flag.Bool("verbose", false, "Verbose mode (no UI)")
+	headless := flag.Bool("headless", false, "Print a status line periodically instead of the UI. Default if stdout is not a terminal")
+	statusInterval := flag.Duration("status-interval", 10*time.Second, "Interval of the status line in headless mode")
+	http3 := flag.Bool("http3", false, "Send requests via HTTP/3 (QUIC)")
+	keepAlive := flag.Bool("keepalive", true, "Reuse connections. If false, every request opens a new connection")
+	maxConnsPerHost := flag.Int("max-conns-per-host", 0, "Max connections per host (0 = no limit)")
+	maxIdle := flag.Int("max-idle", 100, "Max idle connections per host")
+	idleTimeout := flag.Duration("idle-timeout", 90*time.Second, "Close idle connections after this time (0 = no limit)")
+	connections := flag.Int("connections", 0, "Spread requests over a fixed number of connections per host (0 = disabled). Requires -keepalive")
+	sourceIPs := flag.String("source-ips", "", "Comma separated list of local IP addresses or interface names used in turn for new connections")
+	var resolve stringList
+	flag.Var(&resolve, "resolve", "Resolve host:port to the given addresses, host:port:addr[,addr]... Can be given multiple times")
+	dnsCacheTTL := flag.Duration("dns-cache", 0, "Cache DNS lookups for the given duration (0 = disabled)")
+	unixSocket := flag.String("unix-socket", "", "Send all requests to this Unix domain socket")
+	proxy := flag.String("proxy", "", "Proxy URL (http://, https:// or socks5://), also used for localhost. Default is taken from HTTP_PROXY, HTTPS_PROXY and NO_PROXY")
+	flag.Usage = func() {
+		_, _ = fmt.Fprintf(flag.CommandLine.Output(), "Usage: slapperx -targets file [options]\n")
+		_, _ = fmt.Fprintf(flag.CommandLine.Output(), "       slapperx report [options] logfile\n")
+		_, _ = fmt.Fprintf(flag.CommandLine.Output(), "       slapperx compare [options] baseline.json candidate.json\n\nOptions:\n")
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 	if len(*targets) == 0 {
 		flag.Usage()
@@ -45,6 +129,37 @@ func ParseFlags() *Config {
 		MaxY:      *maxY,
 		RampUp:    *rampUp,
 		LogFile:   *logFile,
+		LogFormat: *logFormat,
 		Verbose:   *verbose,
+		HTTP3:     *http3,
+
+		KeepAlive:       *keepAlive,
+		MaxConnsPerHost: *maxConnsPerHost,
+		MaxIdle:         *maxIdle,
+		IdleTimeout:     *idleTimeout,
+		Connections:     *connections,
+		Proxy:           *proxy,
+		SourceIPs:       *sourceIPs,
+		Resolve:         resolve,
+		DNSCacheTTL:     *dnsCacheTTL,
+		UnixSocket:      *unixSocket,
+		HTMLReport:      *htmlReport,
+		Summary:         *summary,
+		Duration:        *duration,
+		Thresholds:      thresholds,
+		ThresholdAbort:  *thresholdAbort,
+		JUnit:           *junit,
+		MetricsAddr:     *metricsAddr,
+		StatsD:          *statsD,
+		Influx:          *influx,
+		OTLP:            *otlp,
+		PushInterval:    *pushInterval,
+		Web:             *webAddr,
+		ControlAddr:     *controlAddr,
+		Profiles:        profiles,
+		Scale:           *scale,
+		BucketEdges:     *bucketEdges,
+		Headless:        *headless,
+		StatusInterval:  *statusInterval,
 	}
 }
@@ -0,0 +1,87 @@
+package slapperx
+
+import (
+	"fmt"
+	"github.com/s-macke/slapperx/src/metrics"
+	"github.com/s-macke/slapperx/src/report"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Headless prints a status line periodically instead of the terminal UI, e.g. in CI logs or containers
+type Headless struct {
+	period   time.Duration
+	interval *metrics.Interval // requests finished since the last status line
+	start    time.Time
+	quit     chan struct{}
+	stopOnce sync.Once
+}
+
+func NewHeadless(period time.Duration) *Headless {
+	return &Headless{
+		period:   period,
+		interval: stats.metrics.NewInterval(),
+		start:    time.Now(),
+		quit:     make(chan struct{}),
+	}
+}
+
+// Start prints the status line every period
+func (h *Headless) Start() {
+	go func() {
+		last := time.Now()
+		ticker := time.NewTicker(h.period)
+		defer ticker.Stop()
+		for {
+			select {
+			case now := <-ticker.C:
+				h.printStatus(now.Sub(last))
+				last = now
+			case <-h.quit:
+				return
+			}
+		}
+	}()
+}
+
+// printStatus prints the rate and latency of the last period and the totals
+func (h *Headless) printStatus(elapsed time.Duration) {
+	overall := h.interval.Take().Overall
+	total := stats.summary.Summary().Overall
+	fmt.Printf("time: %5ds rate: %.1f/%.1f RPS sent: %d in-flight: %d errors: %d (%.2f%%) p50: %.1fms p99: %.1fms\n",
+		int(time.Since(h.start).Seconds()),
+		float64(overall.Requests)/elapsed.Seconds(), stats.getSetRate(),
+		stats.requestsSent.Load(), stats.getInFlightRequests(),
+		total.Errors, total.ErrorRate*100,
+		overall.Latency.P50Ms, overall.Latency.P99Ms)
+}
+
+// Stop ends Wait. It can be called multiple times.
+func (h *Headless) Stop() {
+	h.stopOnce.Do(func() {
+		close(h.quit)
+	})
+}
+
+// Wait blocks until Stop is called or the process receives SIGINT or SIGTERM
+func (h *Headless) Wait() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	select {
+	case <-signals:
+		h.Stop()
+	case <-h.quit:
+	}
+}
+
+// PrintSummary prints the summary of the run since the start or the last reset
+func (h *Headless) PrintSummary() {
+	fmt.Println("Summary:")
+	if err := report.WriteSummaryText(os.Stdout, stats.summary.Summary()); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Failed to write summary: %v\n", err)
+	}
+}
@@ -0,0 +1,121 @@
+package slapperx
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	heatmapColumns     = 1024 // seconds kept, more than the width of any terminal
+	heatmapLabelWidth  = 17
+	heatmapEmptyColumn = " "
+)
+
+// heatColors are the 256 color codes from few to many requests
+var heatColors = []int{17, 19, 21, 27, 33, 39, 45, 51, 50, 48, 46, 82, 118, 154, 190, 226, 220, 214, 208, 202, 196}
+
+// Heatmap counts the requests per latency bucket in columns of one second. It is a ring buffer of the
+// last heatmapColumns seconds and is guarded by the lock of the MovingWindow.
+type Heatmap struct {
+	columns [][]int
+	last    int64 // unix second of the newest column
+}
+
+func NewHeatmap(nbuckets int) *Heatmap {
+	h := &Heatmap{columns: make([][]int, heatmapColumns)}
+	for i := range h.columns {
+		h.columns[i] = make([]int, nbuckets)
+	}
+	return h
+}
+
+func (h *Heatmap) add(end time.Time, bucket int) {
+	second := end.Unix()
+	if second <= h.last-heatmapColumns {
+		return
+	}
+	// clear the columns skipped since the newest one
+	for s := max(h.last+1, second-heatmapColumns+1); s <= second; s++ {
+		clear(h.columns[s%heatmapColumns])
+	}
+	h.last = max(h.last, second)
+	h.columns[second%heatmapColumns][bucket]++
+}
+
+func (h *Heatmap) reset() {
+	for _, column := range h.columns {
+		clear(column)
+	}
+}
+
+// resize moves the counts to the new buckets
+func (h *Heatmap) resize(mapping []int, nbuckets int) {
+	for i, column := range h.columns {
+		counts := make([]int, nbuckets)
+		for bkt, c := range column {
+			counts[mapping[bkt]] += c
+		}
+		h.columns[i] = counts
+	}
+}
+
+// data returns a copy of the last n columns until now, the oldest first, and the maximum count
+func (h *Heatmap) data(now time.Time, n int) ([][]int, int) {
+	n = min(n, heatmapColumns)
+	columns := make([][]int, n)
+	maximum := 0
+	second := now.Unix()
+	for i := range columns {
+		s := second - int64(n-1-i)
+		if s > h.last || s <= h.last-heatmapColumns {
+			continue
+		}
+		columns[i] = append([]int(nil), h.columns[s%heatmapColumns]...)
+		for _, c := range columns[i] {
+			maximum = max(maximum, c)
+		}
+	}
+	return columns, maximum
+}
+
+// heatmapData returns the last n seconds of the heatmap
+func (mw *MovingWindow) heatmapData(now time.Time, n int) ([][]int, int) {
+	mw.mu.Lock()
+	defer mw.mu.Unlock()
+	return mw.heatmap.data(now, n)
+}
+
+// drawHeatmap draws the requests per latency bucket and second, the newest second on the right.
+// The colors are scaled logarithmically, so that rare slow requests are still visible.
+func (ui *UI) drawHeatmap(sb *strings.Builder) {
+	width := max(ui.plotWidth-heatmapLabelWidth-1, 0)
+	columns, maximum := stats.timings.heatmapData(time.Now(), width)
+	scale := float64(len(heatColors)-1) / math.Log1p(float64(maximum))
+
+	for bkt := 0; bkt < ui.lbc.buckets; bkt++ {
+		_, _ = fmt.Fprintf(sb, "%11s ms: │", ui.lbc.createLabel(bkt))
+		color := -1
+		for _, column := range columns {
+			if column == nil || column[bkt] == 0 {
+				if color >= 0 {
+					sb.WriteString("\033[0m")
+					color = -1
+				}
+				sb.WriteString(heatmapEmptyColumn)
+				continue
+			}
+			level := min(int(math.Ceil(math.Log1p(float64(column[bkt]))*scale)), len(heatColors)-1)
+			if c := heatColors[level]; c != color {
+				color = c
+				_, _ = fmt.Fprintf(sb, "\033[38;5;%dm", color)
+			}
+			sb.WriteString("█")
+		}
+		sb.WriteString("\033[0m\033[K")
+		if bkt < ui.lbc.buckets-1 {
+			sb.WriteString("\r\n")
+		}
+	}
+}
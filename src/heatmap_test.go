@@ -0,0 +1,69 @@
+package slapperx
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHeatmapRing(t *testing.T) {
+	const b = 1_000_000 // unix second of the start
+	cleared := make([][]int, heatmapColumns)
+	for i := range cleared {
+		cleared[i] = []int{0, 0}
+	}
+	cleared[heatmapColumns-1] = []int{1, 0}
+
+	type sample struct {
+		second int64
+		bucket int
+	}
+	tests := []struct {
+		name     string
+		samples  []sample
+		now      int64
+		n        int
+		expected [][]int // nil for columns without data
+		maximum  int
+	}{
+		{"empty", nil, b, 2, [][]int{nil, nil}, 0},
+		{"one second", []sample{{b, 1}, {b, 1}, {b, 0}}, b, 2, [][]int{{0, 0}, {1, 2}}, 2},
+		{"gap", []sample{{b, 0}, {b + 2, 1}}, b + 2, 3, [][]int{{1, 0}, {0, 0}, {0, 1}}, 1},
+		{"out of order", []sample{{b + 2, 1}, {b + 1, 0}}, b + 2, 2, [][]int{{1, 0}, {0, 1}}, 1},
+		{"before now", []sample{{b, 1}}, b + 3, 4, [][]int{{0, 1}, nil, nil, nil}, 1},
+		{"too old", []sample{{b + heatmapColumns, 0}, {b, 1}}, b + heatmapColumns, 1, [][]int{{1, 0}}, 1},
+		{"cleared after a wrap", []sample{{b, 1}, {b + heatmapColumns, 0}}, b + heatmapColumns, heatmapColumns, cleared, 1},
+		{"more columns than kept", []sample{{b, 1}}, b, heatmapColumns + 5, nil, 1},
+	}
+	for _, test := range tests {
+		h := NewHeatmap(2)
+		for _, s := range test.samples {
+			h.add(time.Unix(s.second, 0), s.bucket)
+		}
+		columns, maximum := h.data(time.Unix(test.now, 0), test.n)
+		if maximum != test.maximum {
+			t.Errorf("%s: expected maximum %d, got %d", test.name, test.maximum, maximum)
+		}
+		if test.expected == nil {
+			if len(columns) != heatmapColumns || columns[heatmapColumns-1][1] != 1 {
+				t.Errorf("%s: expected the last %d columns", test.name, heatmapColumns)
+			}
+			continue
+		}
+		if len(columns) != len(test.expected) {
+			t.Errorf("%s: expected %d columns, got %d", test.name, len(test.expected), len(columns))
+			continue
+		}
+		for i, column := range columns {
+			if (column == nil) != (test.expected[i] == nil) {
+				t.Errorf("%s: column %d: expected %v, got %v", test.name, i, test.expected[i], column)
+				continue
+			}
+			for bkt := range column {
+				if column[bkt] != test.expected[i][bkt] {
+					t.Errorf("%s: column %d: expected %v, got %v", test.name, i, test.expected[i], column)
+					break
+				}
+			}
+		}
+	}
+}
@@ -3,7 +3,6 @@
 import (
 	"bytes"
 	"encoding/json"
-	"net/http"
 	"net/url"
 	"os"
 	"strings"
@@ -34,7 +33,7 @@
 )
 
 type Parser struct {
-	reqs           []http.Request
+	reqs           []Request
 	req            HTTPFile
 	content        string
 	currentLineNum int
@@ -75,6 +74,9 @@ func isValidMethodLine(line string) bool {
 	if strings.HasPrefix(line, "http://") || strings.HasPrefix(line, "https://") {
 		return true
 	}
+	if strings.HasPrefix(line, "http+unix://") || strings.HasPrefix(line, "https+unix://") {
+		return true
+	}
 	for method := range HTTPMethods {
 		if strings.HasPrefix(line, method+" ") || strings.HasPrefix(line, method+"\t") {
 			return true
@@ -98,6 +100,14 @@ func validateURL(rawURL string) error {
 		return NewParseError(ErrInvalidURL, "URL must have a scheme (http:// or https://)", rawURL)
 	}
 
+	if strings.HasSuffix(parsedURL.Scheme, "+unix") {
+		// http+unix:///var/run/app.sock:/path
+		if parsedURL.Path == "" {
+			return NewParseError(ErrInvalidURL, "URL must contain a socket path", rawURL)
+		}
+		return nil
+	}
+
 	if parsedURL.Host == "" {
 		return NewParseError(ErrInvalidURL, "URL must have a host", rawURL)
 	}
@@ -323,11 +333,11 @@ func (p *Parser) parse(addKeepAlive bool) error {
 				return EnrichParseError(err, line, p.currentLineNum)
 			}
 			fillParameters(&p.req)
-			req, err := PrepareRequest(p.req, addKeepAlive)
+			req, err := NewRequest(p.req, addKeepAlive)
 			if err != nil {
 				return err
 			}
-			p.reqs = append(p.reqs, *req)
+			p.reqs = append(p.reqs, req)
 			p.req = NewHTTPFile()
 		}
 		if newpart != part {
@@ -344,17 +354,17 @@ func (p *Parser) parse(addKeepAlive bool) error {
 			return EnrichParseError(err, "", p.currentLineNum)
 		}
 		fillParameters(&p.req)
-		req, err := PrepareRequest(p.req, addKeepAlive)
+		req, err := NewRequest(p.req, addKeepAlive)
 		if err != nil {
 			return err
 		}
-		p.reqs = append(p.reqs, *req)
+		p.reqs = append(p.reqs, req)
 		p.req = NewHTTPFile()
 	}
 	return nil
 }
 
-func HTTPFileParser(path string, overridesPath string, addKeepAlive bool) ([]http.Request, error) {
+func HTTPFileParser(path string, overridesPath string, addKeepAlive bool) ([]Request, error) {
 	httpFile, err := template.ParseGlob(path)
 	if err != nil {
 		return nil, NewParseErrorWithCause(ErrTemplateError, "failed to parse HTTP template file", "", err)
@@ -182,6 +182,56 @@ func TestParseDELETERequest(t *testing.T) {
 	}
 }
 
+func TestParseUnixSocketRequest(t *testing.T) {
+	data, err := os.ReadFile("testdata/unix_socket.http")
+	if err != nil {
+		t.Fatalf("Failed to read testdata/unix_socket.http: %v", err)
+	}
+	httpText := string(data)
+	parser := newParser(httpText)
+	err = parser.parse(false)
+	if err != nil {
+		t.Fatalf("Parser error: %v", err)
+	}
+	if len(parser.reqs) != 2 {
+		t.Fatalf("Expected 2 requests, got %d", len(parser.reqs))
+	}
+	if parser.reqs[0].URL.String() != "http+unix:///var/run/app.sock:/health?verbose=1" {
+		t.Errorf("Expected URL http+unix:///var/run/app.sock:/health?verbose=1, got %s", parser.reqs[0].URL.String())
+	}
+	if parser.reqs[1].Method != "GET" {
+		t.Errorf("Expected method GET, got %s", parser.reqs[1].Method)
+	}
+}
+
+func TestParseNamedRequests(t *testing.T) {
+	data, err := os.ReadFile("testdata/named_requests.http")
+	if err != nil {
+		t.Fatalf("Failed to read testdata/named_requests.http: %v", err)
+	}
+	httpText := string(data)
+	parser := newParser(httpText)
+	err = parser.parse(false)
+	if err != nil {
+		t.Fatalf("Parser error: %v", err)
+	}
+	if len(parser.reqs) != 2 {
+		t.Fatalf("Expected 2 requests, got %d", len(parser.reqs))
+	}
+	if parser.reqs[0].Name != "list users" {
+		t.Errorf("Expected name 'list users', got '%s'", parser.reqs[0].Name)
+	}
+	if len(parser.reqs[0].Tags) != 2 || parser.reqs[0].Tags[0] != "users" || parser.reqs[0].Tags[1] != "read" {
+		t.Errorf("Expected tags [users read], got %v", parser.reqs[0].Tags)
+	}
+	if parser.reqs[1].Name != "POST http://example.com/users" {
+		t.Errorf("Expected name 'POST http://example.com/users', got '%s'", parser.reqs[1].Name)
+	}
+	if len(parser.reqs[1].Tags) != 0 {
+		t.Errorf("Expected no tags, got %v", parser.reqs[1].Tags)
+	}
+}
+
 func TestParseNoHeadersNoBody(t *testing.T) {
 	data, err := os.ReadFile("testdata/no_headers_no_body.http")
 	if err != nil {
@@ -465,6 +515,17 @@ func TestValidateURL(t *testing.T) {
 			shouldErr: true,
 			errorType: ErrInvalidURL,
 		},
+		{
+			name:      "valid Unix socket URL",
+			url:       "http+unix:///var/run/app.sock:/health",
+			shouldErr: false,
+		},
+		{
+			name:      "Unix socket URL without socket",
+			url:       "http+unix://",
+			shouldErr: true,
+			errorType: ErrInvalidURL,
+		},
 		{
 			name:      "malformed URL",
 			url:       "http://[invalid",
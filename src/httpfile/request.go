@@ -37,6 +37,32 @@ func AuthToBase64(header HTTPHeader) HTTPHeader {
 	return header
 }
 
+// Request is a prepared HTTP request together with its name and tags from the .http file
+type Request struct {
+	http.Request
+	Name   string
+	Tags   []string
+	RawURL string // the URL formatted once, for the log records
+}
+
+// NewRequest prepares the HTTP request. Requests without @Name are named by method and URL.
+func NewRequest(r HTTPFile, addKeepAlive bool) (Request, error) {
+	req, err := PrepareRequest(r, addKeepAlive)
+	if err != nil {
+		return Request{}, err
+	}
+	name := r.Name
+	if name == "" {
+		name = r.Method + " " + r.URL
+	}
+	return Request{
+		Request: *req,
+		Name:    name,
+		Tags:    r.Tags,
+		RawURL:  req.URL.String(),
+	}, nil
+}
+
 func PrepareRequest(r HTTPFile, addKeepAlive bool) (*http.Request, error) {
 	req, err := http.NewRequest(r.Method, r.URL, strings.NewReader(r.Body))
 	if err != nil {
@@ -4,32 +4,103 @@
 	term "github.com/nsf/termbox-go"
 )
 
+// workersStep is the number of workers started or stopped with one key press
+const workersStep = 10
+
 // InitKeyboard initializes the keyboard with default handlers for the application
-func InitKeyboard(rampUpController *RampUpController) *Keyboard {
+func InitKeyboard(controller *Controller) *Keyboard {
 	keyboard := NewKeyboard()
 
 	// Register rate change handlers
-	keyboard.RegisterHandler('j', func() {
-		rampUpController.DecreaseRate()
+	keyboard.RegisterHandler('j', "Decrease the rate by 10", func() {
+		_ = controller.ChangeRate(rateDecreaseStep)
+	})
+
+	keyboard.RegisterHandler('k', "Increase the rate by 10", func() {
+		_ = controller.ChangeRate(rateIncreaseStep)
 	})
 
-	keyboard.RegisterHandler('k', func() {
-		rampUpController.IncreaseRate()
+	// Register worker and timeout handlers
+	keyboard.RegisterHandler('w', "Stop 10 workers", func() {
+		controller.ChangeWorkers(-workersStep)
+	})
+	keyboard.RegisterHandler('W', "Start 10 more workers", func() {
+		controller.ChangeWorkers(workersStep)
+	})
+	keyboard.RegisterHandler('o', "Halve the request timeout", func() {
+		controller.ScaleTimeout(0.5)
 	})
+	keyboard.RegisterHandler('O', "Double the request timeout", func() {
+		controller.ScaleTimeout(2)
+	})
+
+	// Register pause handler
+	keyboard.RegisterHandler('p', "Pause or resume sending requests", controller.TogglePause)
 
 	// Register stats reset handler
-	keyboard.RegisterHandler('r', func() {
-		stats.reset()
+	keyboard.RegisterHandler('r', "Reset the statistics", func() {
+		controller.Reset()
 	})
 
+	// Register the re-layout of the histogram and the view switch
+	if ui != nil {
+		keyboard.RegisterResizeHandler(ui.Resize)
+		keyboard.RegisterSpecialHandler(term.KeyTab, "Tab", "Switch to the next view", ui.NextView)
+		for v := range viewCount {
+			keyboard.RegisterHandler(rune('1'+v), "Show the "+v.String(), func() {
+				ui.SetView(v)
+			})
+		}
+		keyboard.RegisterHandler('t', "Switch between the histogram and the time series", func() {
+			ui.ToggleView(viewTimeSeries)
+		})
+		keyboard.RegisterHandler('h', "Switch between the histogram and the heatmap", func() {
+			ui.ToggleView(viewHeatmap)
+		})
+
+		// Register the Y axis handlers
+		keyboard.RegisterHandler('+', "Zoom into the latency range", func() {
+			ui.Zoom(1. / zoomFactor)
+		})
+		keyboard.RegisterHandler('-', "Zoom out of the latency range", func() {
+			ui.Zoom(zoomFactor)
+		})
+		keyboard.RegisterHandler('[', "Shift the latency range to faster requests", func() {
+			ui.Pan(-panFraction)
+		})
+		keyboard.RegisterHandler(']', "Shift the latency range to slower requests", func() {
+			ui.Pan(panFraction)
+		})
+		keyboard.RegisterHandler('s', "Switch between log, linear and custom scale", ui.CycleScale)
+		keyboard.RegisterHandler('c', "Filter the histogram by status class: 2xx, 3xx, 4xx, 5xx, error or all", ui.CycleFilter)
+		keyboard.RegisterHandler('a', "Fit the latency range to the observed latencies", ui.ToggleAutoFit)
+		keyboard.RegisterHandler('?', "Show or hide this help", ui.ToggleHelp)
+
+		// Register the command prompt
+		prompt := NewPrompt(controller.Execute)
+		keyboard.RegisterPrompt(':', "Enter a command: rate, workers, timeout, profile, note, enable, disable, toggle or filter", prompt)
+		keyboard.RegisterHandler('n', "Add an annotation, e.g. deploy started", func() {
+			prompt.OpenWith("note ")
+		})
+		keyboard.RegisterHandler('e', "Enable or disable requests by number, name or @tag", func() {
+			ui.SetView(viewEndpoints)
+			prompt.OpenWith("toggle ")
+		})
+		ui.SetPrompt(prompt)
+	}
+
 	// Register quit handlers
-	keyboard.RegisterHandler('q', func() {
+	keyboard.RegisterHandler('q', "Quit", func() {
 		keyboard.Stop()
 	})
 
-	keyboard.RegisterSpecialHandler(term.KeyCtrlC, func() {
+	keyboard.RegisterSpecialHandler(term.KeyCtrlC, "Ctrl+C", "Quit", func() {
 		keyboard.Stop()
 	})
 
+	if ui != nil {
+		ui.SetHelp(keyboard.Bindings())
+	}
+	controller.stop = keyboard.Interrupt
 	return keyboard
 }
@@ -3,13 +3,24 @@
 import (
 	term "github.com/nsf/termbox-go"
 	"log"
+	"sync"
 )
 
+// Binding describes a registered key for the help
+type Binding struct {
+	Key         string
+	Description string
+}
+
 // Keyboard represents a keyboard input handler
 type Keyboard struct {
 	handlers        map[rune]func()
 	specialHandlers map[term.Key]func()
+	bindings        []Binding
+	resizeHandler   func()
+	prompt          *Prompt
 	quit            chan struct{}
+	stopOnce        sync.Once
 }
 
 // NewKeyboard creates a new keyboard input handler
@@ -22,13 +33,31 @@ func NewKeyboard() *Keyboard {
 }
 
 // RegisterHandler registers a handler function for a specific key
-func (k *Keyboard) RegisterHandler(key rune, handler func()) {
+func (k *Keyboard) RegisterHandler(key rune, description string, handler func()) {
 	k.handlers[key] = handler
+	k.bindings = append(k.bindings, Binding{Key: string(key), Description: description})
 }
 
-// RegisterSpecialHandler registers a handler function for a special key
-func (k *Keyboard) RegisterSpecialHandler(key term.Key, handler func()) {
+// RegisterSpecialHandler registers a handler function for a special key with the given name
+func (k *Keyboard) RegisterSpecialHandler(key term.Key, name string, description string, handler func()) {
 	k.specialHandlers[key] = handler
+	k.bindings = append(k.bindings, Binding{Key: name, Description: description})
+}
+
+// Bindings returns the registered keys in the order of registration
+func (k *Keyboard) Bindings() []Binding {
+	return k.bindings
+}
+
+// RegisterResizeHandler registers a handler function which is called when the terminal has been resized
+func (k *Keyboard) RegisterResizeHandler(handler func()) {
+	k.resizeHandler = handler
+}
+
+// RegisterPrompt registers a prompt which is opened with the given key and then receives all keys
+func (k *Keyboard) RegisterPrompt(key rune, description string, prompt *Prompt) {
+	k.prompt = prompt
+	k.RegisterHandler(key, description, prompt.Open)
 }
 
 // Start begins listening for keyboard input
@@ -56,7 +85,9 @@ func() {
 				case term.EventInterrupt:
 					return
 				case term.EventResize:
-					break
+					if k.resizeHandler != nil {
+						k.resizeHandler()
+					}
 				case term.EventError:
 					log.Fatal(ev.Err)
 				default:
@@ -69,11 +100,24 @@ func() {
 
 // Stop terminates the keyboard listener
 func (k *Keyboard) Stop() {
-	close(k.quit)
+	k.stopOnce.Do(func() {
+		close(k.quit)
+	})
+}
+
+// Interrupt terminates the keyboard listener from another goroutine while it waits for input
+func (k *Keyboard) Interrupt() {
+	k.Stop()
+	term.Interrupt()
 }
 
 // handleKeyPress processes key press events and calls the appropriate handler
 func (k *Keyboard) handleKeyPress(ev term.Event) bool {
+	if k.prompt != nil && k.prompt.IsOpen() {
+		k.prompt.handleKey(ev)
+		return false
+	}
+
 	// Check for special keys first
 	if handler, exists := k.specialHandlers[ev.Key]; exists {
 		handler()
@@ -1,75 +1,201 @@
 package slapperx
 
 import (
+	"errors"
 	"fmt"
 	"math"
+	"sort"
+	"strings"
 	"time"
 )
 
+// minRangeMs is the smallest latency range of the Y axis. The log scale needs a range
+// above 1 ms, otherwise all its edges collapse.
+const minRangeMs = 2
+
+// scaleMode is the spacing of the histogram buckets
+type scaleMode int
+
+const (
+	scaleLog    scaleMode = iota // logarithmic between minY and maxY
+	scaleLinear                  // equal width between minY and maxY
+	scaleCustom                  // the edges given with -bucket-edges
+)
+
+var scaleNames = []string{"log", "linear", "custom"}
+
+func (s scaleMode) String() string {
+	return scaleNames[s]
+}
+
+// parseScale parses the name of a scale mode
+func parseScale(name string) (scaleMode, error) {
+	for i, n := range scaleNames {
+		if n == name {
+			return scaleMode(i), nil
+		}
+	}
+	return scaleLog, fmt.Errorf("unknown scale %q, expected log, linear or custom", name)
+}
+
+// parseBucketEdges parses a comma separated list of ascending durations, e.g. 1ms,5ms,10ms
+func parseBucketEdges(list string) ([]float64, error) {
+	if list == "" {
+		return nil, nil
+	}
+	var edges []float64
+	for _, field := range strings.Split(list, ",") {
+		d, err := time.ParseDuration(strings.TrimSpace(field))
+		if err != nil {
+			return nil, fmt.Errorf("invalid bucket edge %q", field)
+		}
+		edge := float64(d) / float64(time.Millisecond)
+		if len(edges) > 0 && edge <= edges[len(edges)-1] {
+			return nil, errors.New("bucket edges must be ascending")
+		}
+		edges = append(edges, edge)
+	}
+	return edges, nil
+}
+
 type logBucketCalculator struct {
 	minY, maxY float64
 
 	// first bucket is for requests faster then minY,
 	// last of for ones slower then maxY
 	buckets int
-	logBase float64
-	startMs float64
+
+	scale       scaleMode
+	customEdges []float64
+	// upper bounds of all buckets but the last one in ms
+	edges []float64
 }
 
 func newLogBucketCalculator(minY time.Duration, maxY time.Duration, buckets int) *logBucketCalculator {
-	lbc := &logBucketCalculator{
-		minY:    float64(minY / time.Millisecond),
-		maxY:    float64(maxY / time.Millisecond),
-		buckets: -1,
-	}
-	lbc.updateBucket(buckets)
+	lbc := &logBucketCalculator{buckets: buckets}
+	lbc.setRange(float64(minY/time.Millisecond), float64(maxY/time.Millisecond))
 	return lbc
 }
 
+// updateBucket sets the number of buckets. Custom edges keep their number of buckets
+// and fall back to the log scale if they don't fit.
 func (lbc *logBucketCalculator) updateBucket(buckets int) {
-	if lbc.buckets == buckets {
-		return
+	if lbc.scale == scaleCustom && len(lbc.customEdges)+1 > buckets {
+		lbc.scale = scaleLog
+	}
+	if lbc.scale == scaleCustom {
+		buckets = len(lbc.customEdges) + 1
 	}
 	lbc.buckets = buckets
 	deltaY := lbc.maxY - lbc.minY
-	lbc.logBase = math.Pow(deltaY, 1./float64(buckets-2))
-	lbc.startMs = lbc.minY + math.Pow(lbc.logBase, 0)
+
+	lbc.edges = make([]float64, buckets-1)
+	for bkt := range lbc.edges {
+		switch lbc.scale {
+		case scaleLog:
+			// bucket k > 0 starts at minY+1+deltaY^((k-1)/(buckets-2))
+			// and the first one ends at minY+1+deltaY^(-1/(buckets-2))
+			exponent := float64(bkt)
+			if bkt == 0 {
+				exponent = -1
+			}
+			lbc.edges[bkt] = lbc.minY + 1 + math.Pow(deltaY, exponent/float64(buckets-2))
+		case scaleLinear:
+			lbc.edges[bkt] = lbc.minY + deltaY*float64(bkt)/float64(buckets-2)
+		case scaleCustom:
+			lbc.edges[bkt] = lbc.customEdges[bkt]
+		}
+	}
 }
 
-func (lbc *logBucketCalculator) calculateBucket(time float64) int {
-	correctedTime := time - lbc.startMs
-	bucket := int(math.Log(correctedTime) / math.Log(lbc.logBase))
+// setRange changes minY and maxY in ms. The range is at least minRangeMs.
+func (lbc *logBucketCalculator) setRange(minY, maxY float64) {
+	lbc.minY = max(minY, 0)
+	lbc.maxY = max(maxY, lbc.minY+minRangeMs)
+	lbc.updateBucket(lbc.buckets)
+}
+
+// zoom multiplies the range by factor, keeping minY
+func (lbc *logBucketCalculator) zoom(factor float64) {
+	lbc.setRange(lbc.minY, lbc.minY+(lbc.maxY-lbc.minY)*factor)
+}
+
+// pan shifts the range by a fraction of its width
+func (lbc *logBucketCalculator) pan(fraction float64) {
+	shift := (lbc.maxY - lbc.minY) * fraction
+	if lbc.minY+shift < 0 {
+		shift = -lbc.minY
+	}
+	lbc.setRange(lbc.minY+shift, lbc.maxY+shift)
+}
+
+// setScale changes the scale mode. Custom is skipped if there are no custom edges.
+func (lbc *logBucketCalculator) setScale(scale scaleMode, buckets int) {
+	if scale == scaleCustom && len(lbc.customEdges) == 0 {
+		scale = scaleLog
+	}
+	lbc.scale = scale
+	lbc.updateBucket(buckets)
+}
 
+func (lbc *logBucketCalculator) calculateBucket(time float64) int {
 	// first bucket is for requests faster than minY,
 	// last of for ones slower then maxY
-	if bucket < 0 {
-		bucket = 0
-	} else if bucket >= int(lbc.buckets)-1 {
-		bucket = lbc.buckets - 1
-	} else {
-		bucket = bucket + 1
+	return sort.Search(len(lbc.edges), func(i int) bool { return time < lbc.edges[i] })
+}
+
+// bucketCenter returns a latency in ms in the middle of a bucket, on the logarithmic scale
+// if the bucket does not start at 0. For the first and the last bucket an estimate is returned.
+func (lbc *logBucketCalculator) bucketCenter(bkt int) float64 {
+	switch bkt {
+	case 0:
+		if lbc.edges[0] <= 0 {
+			return lbc.edges[0] - 1
+		}
+		return lbc.edges[0] / 2
+	case lbc.buckets - 1:
+		return lbc.edges[bkt-1] * 1.5
+	}
+	begin, end := lbc.edges[bkt-1], lbc.edges[bkt]
+	if begin <= 0 {
+		return end / 2
+	}
+	return math.Sqrt(begin * end)
+}
+
+// String describes the scale and range for the header
+func (lbc *logBucketCalculator) String() string {
+	if lbc.scale == scaleCustom {
+		return "custom"
+	}
+	return fmt.Sprintf("%s %s-%sms", lbc.scale, formatMs(lbc.minY), formatMs(lbc.maxY))
+}
+
+func formatMs(ms float64) string {
+	if ms >= 10 {
+		return fmt.Sprintf("%.0f", ms)
 	}
-	return bucket
+	return fmt.Sprintf("%.1f", ms)
 }
 
 // createLabel creates a label for the histogram bucket
 func (lbc *logBucketCalculator) createLabel(bkt int) string {
 	var label string
 	if bkt == 0 {
-		if lbc.startMs >= 10 {
-			label = fmt.Sprintf("<%.0f", lbc.startMs)
+		if lbc.edges[0] >= 10 {
+			label = fmt.Sprintf("<%.0f", lbc.edges[0])
 		} else {
-			label = fmt.Sprintf("<%.1f", lbc.startMs)
+			label = fmt.Sprintf("<%.1f", lbc.edges[0])
 		}
 	} else if bkt == lbc.buckets-1 {
-		if lbc.maxY >= 10 {
-			label = fmt.Sprintf("%3.0f+", lbc.maxY)
+		if lbc.edges[bkt-1] >= 10 {
+			label = fmt.Sprintf("%3.0f+", lbc.edges[bkt-1])
 		} else {
-			label = fmt.Sprintf("%.1f+", lbc.maxY)
+			label = fmt.Sprintf("%.1f+", lbc.edges[bkt-1])
 		}
 	} else {
-		beginMs := lbc.minY + math.Pow(lbc.logBase, float64(bkt-1))
-		endMs := lbc.minY + math.Pow(lbc.logBase, float64(bkt))
+		beginMs := lbc.edges[bkt-1]
+		endMs := lbc.edges[bkt]
 		if endMs >= 10 {
 			label = fmt.Sprintf("%3.0f-%3.0f", beginMs, endMs)
 		} else {
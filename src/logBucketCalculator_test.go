@@ -0,0 +1,141 @@
+package slapperx
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// originalBucket is the log scale bucket of the calculation before the scale modes were added
+func originalBucket(minY, maxY float64, buckets int, t float64) int {
+	logBase := math.Pow(maxY-minY, 1./float64(buckets-2))
+	correctedTime := t - (minY + 1)
+	if correctedTime <= 0 {
+		return 0
+	}
+	bucket := int(math.Log(correctedTime) / math.Log(logBase))
+	if bucket < 0 {
+		return 0
+	} else if bucket >= buckets-1 {
+		return buckets - 1
+	}
+	return bucket + 1
+}
+
+func TestParseBucketEdges(t *testing.T) {
+	tests := []struct {
+		list     string
+		expected []float64
+		fails    bool
+	}{
+		{"", nil, false},
+		{"1ms", []float64{1}, false},
+		{"500us, 1ms,10ms,1s", []float64{0.5, 1, 10, 1000}, false},
+		{"1ms,abc", nil, true},
+		{"1ms,", nil, true},
+		{"10ms,5ms", nil, true},
+		{"5ms,5ms", nil, true},
+	}
+	for _, test := range tests {
+		edges, err := parseBucketEdges(test.list)
+		if (err != nil) != test.fails {
+			t.Errorf("%q: unexpected error %v", test.list, err)
+			continue
+		}
+		if len(edges) != len(test.expected) {
+			t.Errorf("%q: expected %v, got %v", test.list, test.expected, edges)
+			continue
+		}
+		for i := range edges {
+			if edges[i] != test.expected[i] {
+				t.Errorf("%q: expected %v, got %v", test.list, test.expected, edges)
+				break
+			}
+		}
+	}
+}
+
+func TestLogBucketsMatchOriginal(t *testing.T) {
+	tests := []struct {
+		minY, maxY time.Duration
+		buckets    int
+	}{
+		{0, 100 * time.Millisecond, 20},
+		{0, 100 * time.Millisecond, 4},
+		{10 * time.Millisecond, 500 * time.Millisecond, 30},
+		{0, 5 * time.Second, 12},
+	}
+	for _, test := range tests {
+		lbc := newLogBucketCalculator(test.minY, test.maxY, test.buckets)
+		// the samples are off the edges, where rounding decides
+		for ms := 1. / 30; ms < 2*lbc.maxY+10; ms += 0.25 {
+			expected := originalBucket(lbc.minY, lbc.maxY, test.buckets, ms)
+			if bkt := lbc.calculateBucket(ms); bkt != expected {
+				t.Errorf("%v-%v/%d: expected bucket %d for %gms, got %d", test.minY, test.maxY, test.buckets, expected, ms, bkt)
+			}
+		}
+	}
+}
+
+func TestBucketEdges(t *testing.T) {
+	tests := []struct {
+		scale    scaleMode
+		custom   []float64
+		buckets  int
+		expected []float64
+	}{
+		{scaleLog, nil, 4, []float64{1.1, 11, 101}},
+		{scaleLinear, nil, 4, []float64{0, 50, 100}},
+		{scaleCustom, []float64{1, 5, 10}, 20, []float64{1, 5, 10}},
+		// custom edges without enough buckets fall back to the log scale
+		{scaleCustom, []float64{1, 5, 10}, 3, []float64{1 + 1./100, 101}},
+		// custom without edges is skipped
+		{scaleCustom, nil, 4, []float64{1.1, 11, 101}},
+	}
+	for _, test := range tests {
+		lbc := newLogBucketCalculator(0, 100*time.Millisecond, test.buckets)
+		lbc.customEdges = test.custom
+		lbc.setScale(test.scale, test.buckets)
+		if len(lbc.edges) != len(test.expected) || lbc.buckets != len(test.expected)+1 {
+			t.Errorf("%v %v: expected edges %v, got %v", test.scale, test.custom, test.expected, lbc.edges)
+			continue
+		}
+		for i, edge := range lbc.edges {
+			if math.Abs(edge-test.expected[i]) > 1e-9 {
+				t.Errorf("%v %v: expected edges %v, got %v", test.scale, test.custom, test.expected, lbc.edges)
+				break
+			}
+		}
+	}
+}
+
+func TestZoomPan(t *testing.T) {
+	tests := []struct {
+		name       string
+		update     func(lbc *logBucketCalculator)
+		minY, maxY float64
+	}{
+		{"zoom in", func(lbc *logBucketCalculator) { lbc.zoom(0.5) }, 10, 60},
+		{"zoom out", func(lbc *logBucketCalculator) { lbc.zoom(2) }, 10, 210},
+		{"zoom to minimum range", func(lbc *logBucketCalculator) { lbc.zoom(0.001) }, 10, 10 + minRangeMs},
+		{"pan right", func(lbc *logBucketCalculator) { lbc.pan(0.5) }, 60, 160},
+		{"pan left stops at 0", func(lbc *logBucketCalculator) { lbc.pan(-0.5) }, 0, 100},
+		{"negative range", func(lbc *logBucketCalculator) { lbc.setRange(-5, -10) }, 0, minRangeMs},
+	}
+	for _, test := range tests {
+		for _, scale := range []scaleMode{scaleLog, scaleLinear} {
+			lbc := newLogBucketCalculator(10*time.Millisecond, 110*time.Millisecond, 20)
+			lbc.setScale(scale, 20)
+			test.update(lbc)
+			if lbc.minY != test.minY || lbc.maxY != test.maxY {
+				t.Errorf("%s %v: expected range %g-%g, got %g-%g", test.name, scale, test.minY, test.maxY, lbc.minY, lbc.maxY)
+			}
+			for i := 1; i < len(lbc.edges); i++ {
+				if lbc.edges[i] <= lbc.edges[i-1] {
+					t.Errorf("%s %v: edges are not ascending: %v", test.name, scale, lbc.edges)
+					break
+				}
+			}
+		}
+	}
+}
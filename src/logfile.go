@@ -2,6 +2,7 @@
 
 import (
 	"bufio"
+	"github.com/s-macke/slapperx/src/logformat"
 	"os"
 	"sync"
 	"sync/atomic"
@@ -10,13 +11,14 @@
 type LogFile struct {
 	file       *os.File
 	fileWriter *bufio.Writer
+	encoder    *logformat.Encoder
 	isClosed   atomic.Bool
-	c          chan string
+	c          chan logformat.Record
 	done       chan bool
 	sync.WaitGroup
 }
 
-func NewLogFile(logFile string) *LogFile {
+func NewLogFile(logFile string, format logformat.Format) *LogFile {
 	if logFile == "" {
 		return nil
 	}
@@ -24,10 +26,16 @@ func NewLogFile(logFile string) *LogFile {
 	if err != nil {
 		panic(err)
 	}
+	fileWriter := bufio.NewWriterSize(file, 8192)
+	encoder, err := logformat.NewEncoder(fileWriter, format)
+	if err != nil {
+		panic(err)
+	}
 	f := &LogFile{
 		file:       file,
-		fileWriter: bufio.NewWriterSize(file, 8192),
-		c:          make(chan string, 100),
+		fileWriter: fileWriter,
+		encoder:    encoder,
+		c:          make(chan logformat.Record, 100),
 		done:       make(chan bool),
 	}
 	f.isClosed.Store(false)
@@ -37,12 +45,12 @@ func NewLogFile(logFile string) *LogFile {
 
 func (f *LogFile) WriteLoop() {
 	for {
-		s, ok := <-f.c
+		record, ok := <-f.c
 		if !ok {
 			f.done <- true
 			return
 		}
-		_, err := f.fileWriter.WriteString(s)
+		err := f.encoder.Encode(&record)
 		if err != nil {
 			panic(err)
 		}
@@ -54,7 +62,11 @@ func (f *LogFile) Close() {
 	close(f.c)
 	<-f.done // wait for write loop to finish
 
-	err := f.fileWriter.Flush()
+	err := f.encoder.Flush()
+	if err != nil {
+		panic(err)
+	}
+	err = f.fileWriter.Flush()
 	if err != nil {
 		panic(err)
 	}
@@ -64,9 +76,9 @@ func (f *LogFile) Close() {
 	}
 }
 
-func (f *LogFile) WriteString(s string) {
+func (f *LogFile) Write(record logformat.Record) {
 	if f.isClosed.Load() {
 		return
 	}
-	f.c <- s
+	f.c <- record
 }
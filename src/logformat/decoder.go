@@ -0,0 +1,159 @@
+package logformat
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// legacyTimestampLayout is used by log files written before the header row was introduced
+const legacyTimestampLayout = "2006-01-02T15:04:05.999999999"
+
+// legacyHeader are the columns of log files without header row
+var legacyHeader = []string{"timestamp", "offset_ms", "elapsed_ms", "status", "in_flight", "set_rate"}
+
+// Decoder reads records in CSV or JSON lines format. The format is detected from the first character.
+// CSV files without header row are read with the columns of older versions.
+type Decoder struct {
+	reader     *bufio.Reader
+	format     Format
+	csvReader  *csv.Reader
+	jsonReader *json.Decoder
+	columns    map[string]int
+	line       int
+	started    bool
+}
+
+// NewDecoder creates a decoder
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{reader: bufio.NewReader(r)}
+}
+
+func (d *Decoder) init() error {
+	d.started = true
+	first, err := d.reader.Peek(1)
+	if err != nil {
+		return err
+	}
+	if first[0] == '{' {
+		d.format = JSONL
+		d.jsonReader = json.NewDecoder(d.reader)
+		return nil
+	}
+
+	d.format = CSV
+	d.csvReader = csv.NewReader(d.reader)
+	d.csvReader.FieldsPerRecord = -1
+	d.csvReader.ReuseRecord = true
+	header := legacyHeader
+	if first[0] < '0' || first[0] > '9' {
+		d.line++
+		header, err = d.csvReader.Read()
+		if err != nil {
+			return err
+		}
+	}
+	d.columns = make(map[string]int, len(header))
+	for i, name := range header {
+		d.columns[strings.TrimSpace(name)] = i
+	}
+	if _, ok := d.columns["timestamp"]; !ok {
+		return errors.New("log file has no timestamp column")
+	}
+	return nil
+}
+
+// Format returns the detected format of the log file
+func (d *Decoder) Format() Format {
+	return d.format
+}
+
+// Decode reads the next record. At the end of the file io.EOF is returned.
+func (d *Decoder) Decode(r *Record) error {
+	if !d.started {
+		if err := d.init(); err != nil {
+			return err
+		}
+	}
+	d.line++
+	if d.format == JSONL {
+		*r = Record{}
+		if err := d.jsonReader.Decode(r); err != nil {
+			if err == io.EOF {
+				return err
+			}
+			return fmt.Errorf("line %d: %w", d.line, err)
+		}
+		return nil
+	}
+
+	fields, err := d.csvReader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return err
+		}
+		return fmt.Errorf("line %d: %w", d.line, err)
+	}
+	if err := d.decodeCSV(fields, r); err != nil {
+		return fmt.Errorf("line %d: %w", d.line, err)
+	}
+	return nil
+}
+
+func (d *Decoder) decodeCSV(fields []string, r *Record) error {
+	*r = Record{}
+	var err error
+	get := func(name string) string {
+		if idx, ok := d.columns[name]; ok && idx < len(fields) {
+			return fields[idx]
+		}
+		return ""
+	}
+	parseFloat := func(name string) float64 {
+		s := get(name)
+		if s == "" || err != nil {
+			return 0
+		}
+		var v float64
+		v, err = strconv.ParseFloat(s, 64)
+		if err != nil {
+			err = fmt.Errorf("invalid %s: %q", name, s)
+		}
+		return v
+	}
+
+	timestamp := get("timestamp")
+	r.Timestamp, err = time.Parse(time.RFC3339Nano, timestamp)
+	if err != nil {
+		r.Timestamp, err = time.ParseInLocation(legacyTimestampLayout, timestamp, time.Local)
+		if err != nil {
+			return fmt.Errorf("invalid timestamp: %q", timestamp)
+		}
+	}
+	r.OffsetMs = parseFloat("offset_ms")
+	r.ElapsedMs = parseFloat("elapsed_ms")
+	r.Status = int(parseFloat("status"))
+	r.InFlight = int64(parseFloat("in_flight"))
+	r.SetRate = parseFloat("set_rate")
+	r.Name = get("name")
+	r.Method = get("method")
+	r.URL = get("url")
+	r.BytesIn = int64(parseFloat("bytes_in"))
+	r.BytesOut = int64(parseFloat("bytes_out"))
+	r.ErrorClass = get("error_class")
+	r.Error = get("error")
+	r.DNSMs = parseFloat("dns_ms")
+	r.ConnectMs = parseFloat("connect_ms")
+	r.TLSMs = parseFloat("tls_ms")
+	r.TTFBMs = parseFloat("ttfb_ms")
+	r.Worker = int(parseFloat("worker"))
+	r.Reused = get("reused") == "true"
+	r.Annotation = get("annotation")
+	return err
+}
@@ -0,0 +1,136 @@
+package logformat
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func decodeAll(t *testing.T, data string) []Record {
+	d := NewDecoder(strings.NewReader(data))
+	var records []Record
+	for {
+		var r Record
+		err := d.Decode(&r)
+		if err == io.EOF {
+			return records
+		}
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		records = append(records, r)
+	}
+}
+
+func TestDecodeRoundTrip(t *testing.T) {
+	for _, format := range []Format{CSV, JSONL} {
+		var buf bytes.Buffer
+		e, _ := NewEncoder(&buf, format)
+		record := newTestRecord()
+		_ = e.Encode(&record)
+		_ = e.Encode(&record)
+		_ = e.Flush()
+
+		d := NewDecoder(&buf)
+		for i := 0; i < 2; i++ {
+			var decoded Record
+			if err := d.Decode(&decoded); err != nil {
+				t.Fatalf("Format %d: unexpected error: %v", format, err)
+			}
+			decoded.ElapsedMs = record.ElapsedMs // CSV rounds to microseconds
+			if !decoded.Timestamp.Equal(record.Timestamp) {
+				t.Errorf("Format %d: expected timestamp %s, got %s", format, record.Timestamp, decoded.Timestamp)
+			}
+			decoded.Timestamp = record.Timestamp
+			if decoded != record {
+				t.Errorf("Format %d: expected %+v, got %+v", format, record, decoded)
+			}
+		}
+		if d.Format() != format {
+			t.Errorf("Expected format %d, got %d", format, d.Format())
+		}
+		var r Record
+		if err := d.Decode(&r); err != io.EOF {
+			t.Errorf("Format %d: expected EOF, got %v", format, err)
+		}
+	}
+}
+
+func TestDecodeAnnotation(t *testing.T) {
+	for _, format := range []Format{CSV, JSONL} {
+		var buf bytes.Buffer
+		e, _ := NewEncoder(&buf, format)
+		request := newTestRecord()
+		annotation := Record{Timestamp: request.Timestamp, OffsetMs: 2000, Annotation: "deploy, started"}
+		_ = e.Encode(&request)
+		_ = e.Encode(&annotation)
+		_ = e.Flush()
+
+		records := decodeAll(t, buf.String())
+		if len(records) != 2 || records[0].IsAnnotation() || !records[1].IsAnnotation() {
+			t.Fatalf("Format %d: unexpected records %+v", format, records)
+		}
+		if records[1].Annotation != "deploy, started" || records[1].OffsetMs != 2000 {
+			t.Errorf("Format %d: unexpected annotation %+v", format, records[1])
+		}
+	}
+}
+
+func TestDecodeLegacyCSV(t *testing.T) {
+	records := decodeAll(t, "2024-05-01T12:00:00.5,1500,12,200,3,50.0\n2024-05-01T12:00:01,2000,7,0,1,49.5\n")
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 records, got %d", len(records))
+	}
+	expected := time.Date(2024, 5, 1, 12, 0, 0, 500000000, time.Local)
+	if !records[0].Timestamp.Equal(expected) {
+		t.Errorf("Expected timestamp %s, got %s", expected, records[0].Timestamp)
+	}
+	if records[0].OffsetMs != 1500 || records[0].ElapsedMs != 12 || records[0].Status != 200 ||
+		records[0].InFlight != 3 || records[0].SetRate != 50 {
+		t.Errorf("Unexpected record %+v", records[0])
+	}
+	if records[1].Status != 0 || records[1].SetRate != 49.5 {
+		t.Errorf("Unexpected record %+v", records[1])
+	}
+}
+
+func TestDecodeColumnsByName(t *testing.T) {
+	records := decodeAll(t, "status,timestamp,elapsed_ms,unknown\n404,2024-05-01T12:00:00Z,3.5,x\n")
+	if len(records) != 1 || records[0].Status != 404 || records[0].ElapsedMs != 3.5 {
+		t.Errorf("Unexpected records %+v", records)
+	}
+}
+
+func TestDecodeEmpty(t *testing.T) {
+	if records := decodeAll(t, ""); len(records) != 0 {
+		t.Errorf("Expected no records, got %d", len(records))
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"no timestamp column", "status,elapsed_ms\n200,1\n"},
+		{"invalid timestamp", "timestamp,status\nyesterday,200\n"},
+		{"invalid number", "timestamp,status\n2024-05-01T12:00:00Z,ok\n"},
+		{"invalid JSON", "{\"status\": 200}\n{invalid\n"},
+		{"unterminated quote", "timestamp,name\n2024-05-01T12:00:00Z,\"abc\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDecoder(strings.NewReader(tt.data))
+			var err error
+			for err == nil {
+				var r Record
+				err = d.Decode(&r)
+			}
+			if err == io.EOF {
+				t.Errorf("Expected error, got EOF")
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package logformat
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Header contains the column names of the CSV format
+var Header = []string{
+	"timestamp", "offset_ms", "elapsed_ms", "status", "in_flight", "set_rate",
+	"name", "method", "url", "bytes_in", "bytes_out", "error_class", "error",
+	"dns_ms", "connect_ms", "tls_ms", "ttfb_ms", "worker", "reused", "annotation",
+}
+
+// Encoder writes records in CSV or JSON lines format
+type Encoder struct {
+	format     Format
+	csvWriter  *csv.Writer
+	jsonWriter *json.Encoder
+	columns    []string
+}
+
+// NewEncoder creates an encoder. In CSV format the header row is written immediately.
+func NewEncoder(w io.Writer, format Format) (*Encoder, error) {
+	e := &Encoder{format: format}
+	if format == JSONL {
+		e.jsonWriter = json.NewEncoder(w)
+		return e, nil
+	}
+	e.csvWriter = csv.NewWriter(w)
+	e.columns = make([]string, len(Header))
+	if err := e.csvWriter.Write(Header); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Encode writes a single record
+func (e *Encoder) Encode(r *Record) error {
+	if e.format == JSONL {
+		return e.jsonWriter.Encode(r)
+	}
+	c := e.columns
+	c[0] = r.Timestamp.Format(time.RFC3339Nano)
+	c[1] = formatMs(r.OffsetMs)
+	c[2] = formatMs(r.ElapsedMs)
+	c[3] = strconv.Itoa(r.Status)
+	c[4] = strconv.FormatInt(r.InFlight, 10)
+	c[5] = strconv.FormatFloat(r.SetRate, 'f', 1, 64)
+	c[6] = r.Name
+	c[7] = r.Method
+	c[8] = r.URL
+	c[9] = strconv.FormatInt(r.BytesIn, 10)
+	c[10] = strconv.FormatInt(r.BytesOut, 10)
+	c[11] = r.ErrorClass
+	c[12] = r.Error
+	c[13] = formatMs(r.DNSMs)
+	c[14] = formatMs(r.ConnectMs)
+	c[15] = formatMs(r.TLSMs)
+	c[16] = formatMs(r.TTFBMs)
+	c[17] = strconv.Itoa(r.Worker)
+	c[18] = strconv.FormatBool(r.Reused)
+	c[19] = r.Annotation
+	return e.csvWriter.Write(c)
+}
+
+// Flush writes buffered CSV data to the underlying writer
+func (e *Encoder) Flush() error {
+	if e.csvWriter == nil {
+		return nil
+	}
+	e.csvWriter.Flush()
+	return e.csvWriter.Error()
+}
+
+func formatMs(ms float64) string {
+	return strconv.FormatFloat(ms, 'f', 3, 64)
+}
@@ -0,0 +1,106 @@
+package logformat
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestRecord() Record {
+	return Record{
+		Timestamp:  time.Date(2024, 5, 1, 12, 0, 0, 500000000, time.UTC),
+		OffsetMs:   1500,
+		ElapsedMs:  12.3456,
+		Status:     0,
+		InFlight:   3,
+		SetRate:    50,
+		Name:       "get, users",
+		Method:     "GET",
+		URL:        "http://example.com/users?a=1",
+		BytesIn:    0,
+		BytesOut:   12,
+		ErrorClass: "timeout",
+		Error:      `Get "http://example.com/users?a=1": timeout`,
+		DNSMs:      1,
+		ConnectMs:  2,
+		TTFBMs:     9.5,
+		Worker:     7,
+		Reused:     true,
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	if f, err := ParseFormat("CSV"); err != nil || f != CSV {
+		t.Errorf("Expected CSV, got %v, %v", f, err)
+	}
+	if f, err := ParseFormat("jsonl"); err != nil || f != JSONL {
+		t.Errorf("Expected JSONL, got %v, %v", f, err)
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Errorf("Expected error for unknown format")
+	}
+}
+
+func TestEncodeCSV(t *testing.T) {
+	var buf bytes.Buffer
+	e, err := NewEncoder(&buf, CSV)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	record := newTestRecord()
+	if err := e.Encode(&record); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := e.Flush(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected header and one record, got %d lines", len(lines))
+	}
+	if lines[0] != strings.Join(Header, ",") {
+		t.Errorf("Unexpected header %q", lines[0])
+	}
+	expected := `2024-05-01T12:00:00.5Z,1500.000,12.346,0,3,50.0,"get, users",GET,http://example.com/users?a=1,0,12,timeout,"Get ""http://example.com/users?a=1"": timeout",1.000,2.000,0.000,9.500,7,true,`
+	if lines[1] != expected {
+		t.Errorf("Unexpected record\n got: %s\nwant: %s", lines[1], expected)
+	}
+}
+
+func TestEncodeJSONL(t *testing.T) {
+	var buf bytes.Buffer
+	e, err := NewEncoder(&buf, JSONL)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	record := newTestRecord()
+	for i := 0; i < 2; i++ {
+		if err := e.Encode(&record); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if err := e.Flush(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines without header, got %d", len(lines))
+	}
+	var decoded Record
+	if err := json.Unmarshal([]byte(lines[0]), &decoded); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if decoded != record {
+		t.Errorf("Expected %+v, got %+v", record, decoded)
+	}
+}
+
+func TestMilliseconds(t *testing.T) {
+	if ms := Milliseconds(1500 * time.Microsecond); ms != 1.5 {
+		t.Errorf("Expected 1.5, got %f", ms)
+	}
+}
@@ -0,0 +1,69 @@
+package logformat
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Format is the encoding of the log file
+type Format int
+
+const (
+	CSV Format = iota
+	JSONL
+)
+
+// ParseFormat parses the name of a log format
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "csv":
+		return CSV, nil
+	case "jsonl":
+		return JSONL, nil
+	default:
+		return CSV, errors.New("unknown log format: " + name)
+	}
+}
+
+// Record is a single line of the log file and describes one request or, if Annotation is set,
+// a note taken during the run
+type Record struct {
+	Timestamp time.Time `json:"timestamp"`
+	OffsetMs  float64   `json:"offset_ms"` // start of the request relative to the start of the run
+	ElapsedMs float64   `json:"elapsed_ms"`
+	Status    int       `json:"status"` // 0 if the request failed
+	InFlight  int64     `json:"in_flight"`
+	SetRate   float64   `json:"set_rate"`
+
+	Name   string `json:"name"`
+	Method string `json:"method"`
+	URL    string `json:"url"`
+
+	BytesIn  int64 `json:"bytes_in"`
+	BytesOut int64 `json:"bytes_out"`
+
+	ErrorClass string `json:"error_class,omitempty"`
+	Error      string `json:"error,omitempty"`
+
+	// phase timings, 0 if the phase did not happen, e.g. for reused connections
+	DNSMs     float64 `json:"dns_ms"`
+	ConnectMs float64 `json:"connect_ms"`
+	TLSMs     float64 `json:"tls_ms"`
+	TTFBMs    float64 `json:"ttfb_ms"`
+
+	Worker int  `json:"worker"`
+	Reused bool `json:"reused"` // request was sent over an already open connection
+
+	Annotation string `json:"annotation,omitempty"` // only the timestamp and offset are set for annotations
+}
+
+// IsAnnotation returns true if the record is an annotation and not a request
+func (r *Record) IsAnnotation() bool {
+	return r.Annotation != ""
+}
+
+// Milliseconds converts a duration into fractional milliseconds
+func Milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,214 @@
+package metrics
+
+import (
+	"slices"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/s-macke/slapperx/src/logformat"
+	"github.com/s-macke/slapperx/src/report"
+)
+
+// LatencyBuckets are the upper bounds of the latency histogram in seconds
+var LatencyBuckets = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Endpoint contains the counters of one request. All counters only increase.
+type Endpoint struct {
+	Name       string
+	Sent       int64
+	Responses  map[int]int64    // by status code, 0 for failed requests
+	Errors     map[string]int64 // failed requests by error class
+	Buckets    []int64          // requests with a latency up to LatencyBuckets, not cumulative
+	Count      int64            // finished requests
+	SumSeconds float64          // sum of the latencies
+	BytesIn    int64
+	BytesOut   int64
+}
+
+func newEndpoint(name string) *Endpoint {
+	return &Endpoint{
+		Name:      name,
+		Responses: make(map[int]int64),
+		Errors:    make(map[string]int64),
+		Buckets:   make([]int64, len(LatencyBuckets)+1),
+	}
+}
+
+func (e *Endpoint) clone() Endpoint {
+	c := *e
+	c.Responses = make(map[int]int64, len(e.Responses))
+	for k, v := range e.Responses {
+		c.Responses[k] = v
+	}
+	c.Errors = make(map[string]int64, len(e.Errors))
+	for k, v := range e.Errors {
+		c.Errors[k] = v
+	}
+	c.Buckets = append([]int64(nil), e.Buckets...)
+	return c
+}
+
+// merge adds the counters of o
+func (e *Endpoint) merge(o *Endpoint) {
+	e.Sent += o.Sent
+	for k, v := range o.Responses {
+		e.Responses[k] += v
+	}
+	for k, v := range o.Errors {
+		e.Errors[k] += v
+	}
+	for i, n := range o.Buckets {
+		e.Buckets[i] += n
+	}
+	e.Count += o.Count
+	e.SumSeconds += o.SumSeconds
+	e.BytesIn += o.BytesIn
+	e.BytesOut += o.BytesOut
+}
+
+// Gauge is a value read at the time of the snapshot
+type Gauge struct {
+	Name  string
+	Help  string
+	Value func() float64
+}
+
+// collectorShards is the number of endpoint maps of a Collector, each with its own lock.
+// Sent and Add pick the map by worker, Snapshot sums the endpoints of all maps.
+const collectorShards = 16
+
+type collectorShard struct {
+	mu        sync.Mutex
+	endpoints map[string]*Endpoint
+}
+
+func (s *collectorShard) endpoint(name string) *Endpoint {
+	e, ok := s.endpoints[name]
+	if !ok {
+		e = newEndpoint(name)
+		s.endpoints[name] = e
+	}
+	return e
+}
+
+// Collector counts sent and finished requests per request name. It is safe for concurrent use.
+type Collector struct {
+	shards    [collectorShards]collectorShard
+	mu        sync.Mutex                  // serializes the registration of intervals
+	intervals atomic.Pointer[[]*Interval] // read by the workers without locking
+	gauges    []Gauge
+}
+
+func NewCollector() *Collector {
+	c := &Collector{}
+	for i := range c.shards {
+		c.shards[i].endpoints = make(map[string]*Endpoint)
+	}
+	c.intervals.Store(&[]*Interval{})
+	return c
+}
+
+// Interval collects the requests finished since the last call of Take
+type Interval struct {
+	recorder *report.Recorder
+}
+
+// NewInterval registers a new consumer of the finished requests
+func (c *Collector) NewInterval() *Interval {
+	i := &Interval{recorder: report.NewRecorder()}
+	c.mu.Lock()
+	intervals := append(slices.Clone(*c.intervals.Load()), i)
+	c.intervals.Store(&intervals)
+	c.mu.Unlock()
+	return i
+}
+
+// Take returns the summary of the requests finished since the last call
+func (i *Interval) Take() *report.RunSummary {
+	return i.recorder.Take()
+}
+
+// AddGauge registers a gauge. Gauges must be registered before the collector is used.
+func (c *Collector) AddGauge(name, help string, value func() float64) {
+	c.gauges = append(c.gauges, Gauge{Name: name, Help: help, Value: value})
+}
+
+// Sent counts a request which has been sent by the worker
+func (c *Collector) Sent(worker int, name string) {
+	shard := &c.shards[uint(worker)%collectorShards]
+	shard.mu.Lock()
+	shard.endpoint(name).Sent++
+	shard.mu.Unlock()
+}
+
+// Add counts a finished request
+func (c *Collector) Add(record *logformat.Record) {
+	seconds := record.ElapsedMs / 1000
+	idx := sort.SearchFloat64s(LatencyBuckets, seconds)
+
+	shard := &c.shards[uint(record.Worker)%collectorShards]
+	shard.mu.Lock()
+	e := shard.endpoint(record.Name)
+	e.Responses[record.Status]++
+	if record.ErrorClass != "" {
+		e.Errors[record.ErrorClass]++
+	}
+	e.Buckets[idx]++
+	e.Count++
+	e.SumSeconds += seconds
+	e.BytesIn += record.BytesIn
+	e.BytesOut += record.BytesOut
+	shard.mu.Unlock()
+
+	for _, i := range *c.intervals.Load() {
+		i.recorder.Add(record)
+	}
+}
+
+// Snapshot contains a copy of the counters sorted by request name and the current gauge values
+type Snapshot struct {
+	Endpoints []Endpoint
+	Gauges    []GaugeValue
+}
+
+// GaugeValue is the value of a gauge in a snapshot
+type GaugeValue struct {
+	Name  string
+	Help  string
+	Value float64
+}
+
+// Snapshot returns a consistent copy of all counters
+func (c *Collector) Snapshot() Snapshot {
+	var s Snapshot
+	merged := make(map[string]*Endpoint)
+	for i := range c.shards {
+		shard := &c.shards[i]
+		shard.mu.Lock()
+		for name, e := range shard.endpoints {
+			if m, ok := merged[name]; ok {
+				m.merge(e)
+			} else {
+				clone := e.clone()
+				merged[name] = &clone
+			}
+		}
+		shard.mu.Unlock()
+	}
+	for _, e := range merged {
+		s.Endpoints = append(s.Endpoints, *e)
+	}
+	sort.Slice(s.Endpoints, func(i, j int) bool { return s.Endpoints[i].Name < s.Endpoints[j].Name })
+	s.Gauges = c.GaugeValues()
+	return s
+}
+
+// GaugeValues reads the current values of all gauges
+func (c *Collector) GaugeValues() []GaugeValue {
+	values := make([]GaugeValue, len(c.gauges))
+	for i, g := range c.gauges {
+		values[i] = GaugeValue{Name: g.Name, Help: g.Help, Value: g.Value()}
+	}
+	return values
+}
@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Influx pushes the aggregates in the InfluxDB line protocol to a write endpoint like
+// http://localhost:8086/api/v2/write?org=org&bucket=bucket or http://localhost:8086/write?db=db
+type Influx struct {
+	url   string
+	token string
+}
+
+func NewInflux(url string, token string) *Influx {
+	return &Influx{url: url, token: token}
+}
+
+// influxTagEscaper escapes tag values in the line protocol
+var influxTagEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+
+// influxLines returns the aggregate in the line protocol with one line per request and one for the gauges
+func influxLines(a *Aggregate) string {
+	var sb strings.Builder
+	ts := a.End.UnixNano()
+	for i := range a.Endpoints {
+		e := &a.Endpoints[i]
+		_, _ = fmt.Fprintf(&sb, "slapperx,name=%s requests=%di,errors=%di,rate=%g,error_rate=%g,"+
+			"latency_mean_ms=%g,latency_p50_ms=%g,latency_p90_ms=%g,latency_p95_ms=%g,latency_p99_ms=%g,latency_max_ms=%g %d\n",
+			influxTagEscaper.Replace(e.Name), e.Requests, e.Errors, a.Rate(e), e.ErrorRate,
+			e.Latency.MeanMs, e.Latency.P50Ms, e.Latency.P90Ms, e.Latency.P95Ms, e.Latency.P99Ms, e.Latency.MaxMs, ts)
+	}
+	if len(a.Gauges) > 0 {
+		fields := make([]string, len(a.Gauges))
+		for i, g := range a.Gauges {
+			fields[i] = fmt.Sprintf("%s=%g", strings.TrimPrefix(g.Name, "slapperx_"), g.Value)
+		}
+		_, _ = fmt.Fprintf(&sb, "slapperx_run %s %d\n", strings.Join(fields, ","), ts)
+	}
+	return sb.String()
+}
+
+func (i *Influx) Push(a *Aggregate) error {
+	lines := influxLines(a)
+	if lines == "" {
+		return nil
+	}
+	req, err := http.NewRequest(http.MethodPost, i.url, bytes.NewBufferString(lines))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if i.token != "" {
+		req.Header.Set("Authorization", "Token "+i.token)
+	}
+	resp, err := pushClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("influx: %s", resp.Status)
+	}
+	return nil
+}
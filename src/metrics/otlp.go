@@ -0,0 +1,147 @@
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// OTLP pushes the aggregates to an OpenTelemetry collector via OTLP/HTTP with JSON encoding,
+// for example to http://localhost:4318/v1/metrics
+type OTLP struct {
+	url string
+}
+
+func NewOTLP(url string) *OTLP {
+	return &OTLP{url: url}
+}
+
+// aggregationTemporalityDelta marks sums which only contain the values of the interval
+const aggregationTemporalityDelta = 1
+
+type otlpValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpDataPoint struct {
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano,omitempty"`
+	TimeUnixNano      string          `json:"timeUnixNano"`
+	AsInt             string          `json:"asInt,omitempty"`
+	AsDouble          *float64        `json:"asDouble,omitempty"`
+}
+
+type otlpSum struct {
+	DataPoints             []otlpDataPoint `json:"dataPoints"`
+	AggregationTemporality int             `json:"aggregationTemporality"`
+	IsMonotonic            bool            `json:"isMonotonic"`
+}
+
+type otlpGauge struct {
+	DataPoints []otlpDataPoint `json:"dataPoints"`
+}
+
+type otlpMetric struct {
+	Name  string     `json:"name"`
+	Unit  string     `json:"unit,omitempty"`
+	Sum   *otlpSum   `json:"sum,omitempty"`
+	Gauge *otlpGauge `json:"gauge,omitempty"`
+}
+
+type otlpScopeMetrics struct {
+	Scope struct {
+		Name string `json:"name"`
+	} `json:"scope"`
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	} `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpRequest struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+// otlpMetrics converts the aggregate into delta sums for the counts and gauges for rates and latencies
+func otlpMetrics(a *Aggregate) []otlpMetric {
+	start := strconv.FormatInt(a.Start.UnixNano(), 10)
+	end := strconv.FormatInt(a.End.UnixNano(), 10)
+	sum := func(name string, value func(i int) int64) otlpMetric {
+		m := otlpMetric{Name: name, Unit: "1", Sum: &otlpSum{AggregationTemporality: aggregationTemporalityDelta, IsMonotonic: true}}
+		for i := range a.Endpoints {
+			m.Sum.DataPoints = append(m.Sum.DataPoints, otlpDataPoint{
+				Attributes:        []otlpAttribute{{Key: "name", Value: otlpValue{a.Endpoints[i].Name}}},
+				StartTimeUnixNano: start,
+				TimeUnixNano:      end,
+				AsInt:             strconv.FormatInt(value(i), 10),
+			})
+		}
+		return m
+	}
+	gauge := func(name, unit string, value func(i int) float64) otlpMetric {
+		m := otlpMetric{Name: name, Unit: unit, Gauge: &otlpGauge{}}
+		for i := range a.Endpoints {
+			v := value(i)
+			m.Gauge.DataPoints = append(m.Gauge.DataPoints, otlpDataPoint{
+				Attributes:   []otlpAttribute{{Key: "name", Value: otlpValue{a.Endpoints[i].Name}}},
+				TimeUnixNano: end,
+				AsDouble:     &v,
+			})
+		}
+		return m
+	}
+
+	e := a.Endpoints
+	metrics := []otlpMetric{
+		sum("slapperx.requests", func(i int) int64 { return e[i].Requests }),
+		sum("slapperx.errors", func(i int) int64 { return e[i].Errors }),
+		gauge("slapperx.rate", "1/s", func(i int) float64 { return a.Rate(&e[i]) }),
+		gauge("slapperx.latency.mean", "ms", func(i int) float64 { return e[i].Latency.MeanMs }),
+		gauge("slapperx.latency.p50", "ms", func(i int) float64 { return e[i].Latency.P50Ms }),
+		gauge("slapperx.latency.p90", "ms", func(i int) float64 { return e[i].Latency.P90Ms }),
+		gauge("slapperx.latency.p95", "ms", func(i int) float64 { return e[i].Latency.P95Ms }),
+		gauge("slapperx.latency.p99", "ms", func(i int) float64 { return e[i].Latency.P99Ms }),
+		gauge("slapperx.latency.max", "ms", func(i int) float64 { return e[i].Latency.MaxMs }),
+	}
+	for _, g := range a.Gauges {
+		v := g.Value
+		metrics = append(metrics, otlpMetric{Name: g.Name, Gauge: &otlpGauge{
+			DataPoints: []otlpDataPoint{{TimeUnixNano: end, AsDouble: &v}},
+		}})
+	}
+	return metrics
+}
+
+func (o *OTLP) Push(a *Aggregate) error {
+	scope := otlpScopeMetrics{Metrics: otlpMetrics(a)}
+	scope.Scope.Name = "slapperx"
+	resource := otlpResourceMetrics{ScopeMetrics: []otlpScopeMetrics{scope}}
+	resource.Resource.Attributes = []otlpAttribute{{Key: "service.name", Value: otlpValue{"slapperx"}}}
+	body := otlpRequest{ResourceMetrics: []otlpResourceMetrics{resource}}
+
+	data, err := json.Marshal(&body)
+	if err != nil {
+		return err
+	}
+	resp, err := pushClient.Post(o.url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("otlp: %s", resp.Status)
+	}
+	return nil
+}
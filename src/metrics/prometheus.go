@@ -0,0 +1,107 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// labelEscaper escapes label values in the Prometheus text format
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// WritePrometheus writes the snapshot in the Prometheus text exposition format
+func WritePrometheus(w io.Writer, s Snapshot) error {
+	bw := bufio.NewWriter(w)
+	header := func(name, metricType, help string) {
+		_, _ = fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+	}
+
+	header("slapperx_requests_sent_total", "counter", "Requests sent.")
+	for _, e := range s.Endpoints {
+		_, _ = fmt.Fprintf(bw, "slapperx_requests_sent_total{name=\"%s\"} %d\n", labelEscaper.Replace(e.Name), e.Sent)
+	}
+
+	header("slapperx_responses_total", "counter", "Finished requests by status code, 0 for failed requests.")
+	for _, e := range s.Endpoints {
+		statuses := make([]int, 0, len(e.Responses))
+		for status := range e.Responses {
+			statuses = append(statuses, status)
+		}
+		sort.Ints(statuses)
+		for _, status := range statuses {
+			_, _ = fmt.Fprintf(bw, "slapperx_responses_total{name=\"%s\",status=\"%d\"} %d\n",
+				labelEscaper.Replace(e.Name), status, e.Responses[status])
+		}
+	}
+
+	header("slapperx_errors_total", "counter", "Failed requests by error class.")
+	for _, e := range s.Endpoints {
+		classes := make([]string, 0, len(e.Errors))
+		for class := range e.Errors {
+			classes = append(classes, class)
+		}
+		sort.Strings(classes)
+		for _, class := range classes {
+			_, _ = fmt.Fprintf(bw, "slapperx_errors_total{name=\"%s\",class=\"%s\"} %d\n",
+				labelEscaper.Replace(e.Name), class, e.Errors[class])
+		}
+	}
+
+	header("slapperx_request_duration_seconds", "histogram", "Latency of the finished requests.")
+	for _, e := range s.Endpoints {
+		name := labelEscaper.Replace(e.Name)
+		var cumulative int64
+		for i, upper := range LatencyBuckets {
+			cumulative += e.Buckets[i]
+			_, _ = fmt.Fprintf(bw, "slapperx_request_duration_seconds_bucket{name=\"%s\",le=\"%s\"} %d\n", name, formatFloat(upper), cumulative)
+		}
+		_, _ = fmt.Fprintf(bw, "slapperx_request_duration_seconds_bucket{name=\"%s\",le=\"+Inf\"} %d\n", name, e.Count)
+		_, _ = fmt.Fprintf(bw, "slapperx_request_duration_seconds_sum{name=\"%s\"} %s\n", name, formatFloat(e.SumSeconds))
+		_, _ = fmt.Fprintf(bw, "slapperx_request_duration_seconds_count{name=\"%s\"} %d\n", name, e.Count)
+	}
+
+	header("slapperx_response_bytes_total", "counter", "Size of the response bodies.")
+	for _, e := range s.Endpoints {
+		_, _ = fmt.Fprintf(bw, "slapperx_response_bytes_total{name=\"%s\"} %d\n", labelEscaper.Replace(e.Name), e.BytesIn)
+	}
+	header("slapperx_request_bytes_total", "counter", "Size of the request bodies.")
+	for _, e := range s.Endpoints {
+		_, _ = fmt.Fprintf(bw, "slapperx_request_bytes_total{name=\"%s\"} %d\n", labelEscaper.Replace(e.Name), e.BytesOut)
+	}
+
+	for _, g := range s.Gauges {
+		metricType := "gauge"
+		if strings.HasSuffix(g.Name, "_total") {
+			metricType = "counter"
+		}
+		header(g.Name, metricType, g.Help)
+		_, _ = fmt.Fprintf(bw, "%s %s\n", g.Name, formatFloat(g.Value))
+	}
+	return bw.Flush()
+}
+
+// Handler serves the metrics of the collector in the Prometheus text format
+func Handler(c *Collector) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = WritePrometheus(w, c.Snapshot())
+	})
+}
+
+// Serve serves the metrics on /metrics in the background until the listener is closed
+func Serve(listener net.Listener, c *Collector) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler(c))
+	go func() {
+		_ = http.Serve(listener, mux)
+	}()
+}
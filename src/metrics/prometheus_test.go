@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/s-macke/slapperx/src/logformat"
+)
+
+func newTestCollector() *Collector {
+	c := NewCollector()
+	addTestRecords(c)
+	return c
+}
+
+func addTestRecords(c *Collector) {
+	c.AddGauge("slapperx_set_rate", "Set request rate per second.", func() float64 { return 50 })
+	for _, record := range []logformat.Record{
+		// spread over the shards of the collector
+		{Name: "users", Status: 200, ElapsedMs: 3, BytesIn: 100},
+		{Name: "users", Status: 200, ElapsedMs: 30, BytesIn: 100, Worker: 1},
+		{Name: "users", Status: 0, ElapsedMs: 2000, ErrorClass: "timeout", Worker: 17},
+		{Name: `item "1"`, Status: 404, ElapsedMs: 0.5},
+	} {
+		c.Sent(record.Worker, record.Name)
+		c.Add(&record)
+	}
+}
+
+func TestWritePrometheus(t *testing.T) {
+	var sb strings.Builder
+	if err := WritePrometheus(&sb, newTestCollector().Snapshot()); err != nil {
+		t.Fatalf("Failed to write metrics: %v", err)
+	}
+	out := sb.String()
+	for _, expected := range []string{
+		"# TYPE slapperx_requests_sent_total counter",
+		`slapperx_requests_sent_total{name="users"} 3`,
+		`slapperx_responses_total{name="users",status="0"} 1`,
+		`slapperx_responses_total{name="users",status="200"} 2`,
+		`slapperx_responses_total{name="item \"1\"",status="404"} 1`,
+		`slapperx_errors_total{name="users",class="timeout"} 1`,
+		"# TYPE slapperx_request_duration_seconds histogram",
+		`slapperx_request_duration_seconds_bucket{name="users",le="0.005"} 1`,
+		`slapperx_request_duration_seconds_bucket{name="users",le="0.05"} 2`,
+		`slapperx_request_duration_seconds_bucket{name="users",le="2.5"} 3`,
+		`slapperx_request_duration_seconds_bucket{name="users",le="+Inf"} 3`,
+		`slapperx_request_duration_seconds_sum{name="users"} 2.033`,
+		`slapperx_request_duration_seconds_count{name="users"} 3`,
+		`slapperx_response_bytes_total{name="users"} 200`,
+		"slapperx_set_rate 50",
+	} {
+		if !strings.Contains(out, expected+"\n") {
+			t.Errorf("Expected %q in metrics:\n%s", expected, out)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	server := httptest.NewServer(Handler(newTestCollector()))
+	defer server.Close()
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to scrape: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Errorf("Unexpected content type %q", resp.Header.Get("Content-Type"))
+	}
+	if !strings.Contains(string(body), "slapperx_requests_sent_total") {
+		t.Errorf("Unexpected body:\n%s", body)
+	}
+}
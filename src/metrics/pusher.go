@@ -0,0 +1,115 @@
+package metrics
+
+import (
+	"net/http"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/s-macke/slapperx/src/report"
+)
+
+// Aggregate contains the requests finished in one push interval and the current gauge values
+type Aggregate struct {
+	Start     time.Time
+	End       time.Time
+	Endpoints []report.EndpointSummary
+	Gauges    []GaugeValue
+}
+
+// Rate returns the finished requests per second of the endpoint in the interval
+func (a *Aggregate) Rate(e *report.EndpointSummary) float64 {
+	seconds := a.End.Sub(a.Start).Seconds()
+	if seconds <= 0 {
+		return 0
+	}
+	return float64(e.Requests) / seconds
+}
+
+// Sink receives the aggregates of every push interval
+type Sink interface {
+	Push(a *Aggregate) error
+}
+
+// Pusher sends the aggregates of the collector periodically to the sinks
+type Pusher struct {
+	collector *Collector
+	interval  *Interval
+	sinks     []Sink
+	period    time.Duration
+
+	last    time.Time
+	pushing sync.WaitGroup
+	busy    atomic.Bool
+
+	errors    atomic.Int64
+	lastError atomic.Value
+}
+
+func NewPusher(collector *Collector, interval time.Duration, sinks ...Sink) *Pusher {
+	return &Pusher{
+		collector: collector,
+		interval:  collector.NewInterval(),
+		sinks:     sinks,
+		period:    interval,
+		last:      time.Now(),
+	}
+}
+
+// Tick pushes the aggregate in the background once the push interval has elapsed.
+// It is called from the aggregation loop and never blocks. A push is skipped while the last one is still running.
+func (p *Pusher) Tick(now time.Time) {
+	if now.Sub(p.last) < p.period || !p.busy.CompareAndSwap(false, true) {
+		return
+	}
+	aggregate := p.aggregate(now)
+	p.pushing.Add(1)
+	go func() {
+		defer p.pushing.Done()
+		defer p.busy.Store(false)
+		p.push(aggregate)
+	}()
+}
+
+// Flush waits for a running push and pushes the remaining requests
+func (p *Pusher) Flush() {
+	p.pushing.Wait()
+	p.push(p.aggregate(time.Now()))
+}
+
+func (p *Pusher) aggregate(now time.Time) *Aggregate {
+	a := &Aggregate{
+		Start:     p.last,
+		End:       now,
+		Endpoints: p.interval.Take().Endpoints,
+		Gauges:    p.collector.GaugeValues(),
+	}
+	p.last = now
+	return a
+}
+
+func (p *Pusher) push(a *Aggregate) {
+	for _, sink := range p.sinks {
+		if err := sink.Push(a); err != nil {
+			p.errors.Add(1)
+			p.lastError.Store(err.Error())
+		}
+	}
+}
+
+// Errors returns the number of failed pushes and the last error
+func (p *Pusher) Errors() (int64, string) {
+	last, _ := p.lastError.Load().(string)
+	return p.errors.Load(), last
+}
+
+var invalidNameChars = regexp.MustCompile(`[^A-Za-z0-9_\-]+`)
+
+// sanitizeName turns a request name into a part of a metric name
+func sanitizeName(name string) string {
+	return invalidNameChars.ReplaceAllString(name, "_")
+}
+
+// pushClient is used by the HTTP sinks
+var pushClient = &http.Client{Timeout: 10 * time.Second}
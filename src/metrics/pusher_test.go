@@ -0,0 +1,182 @@
+package metrics
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/s-macke/slapperx/src/logformat"
+)
+
+// newTestAggregate returns the aggregate of the test collector over one second
+func newTestAggregate() *Aggregate {
+	c := NewCollector()
+	interval := c.NewInterval()
+	addTestRecords(c)
+	end := time.Date(2024, 5, 1, 12, 0, 1, 0, time.UTC)
+	return &Aggregate{
+		Start:     end.Add(-time.Second),
+		End:       end,
+		Endpoints: interval.Take().Endpoints,
+		Gauges:    c.GaugeValues(),
+	}
+}
+
+// httpReceiver records the bodies of the requests
+type httpReceiver struct {
+	mu     sync.Mutex
+	bodies []string
+	header http.Header
+}
+
+func startHTTPReceiver(t *testing.T, status int) (*httpReceiver, string) {
+	receiver := &httpReceiver{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		receiver.mu.Lock()
+		receiver.bodies = append(receiver.bodies, string(body))
+		receiver.header = r.Header
+		receiver.mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return receiver, server.URL
+}
+
+func TestStatsD(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer conn.Close()
+
+	sink, err := NewStatsD(conn.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("Failed to create StatsD sink: %v", err)
+	}
+	if err := sink.Push(newTestAggregate()); err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+
+	var received strings.Builder
+	buf := make([]byte, 2048)
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			break
+		}
+		if n > maxStatsDPacket {
+			t.Errorf("Packet of %d bytes exceeds the maximum", n)
+		}
+		received.Write(buf[:n])
+		received.WriteByte('\n')
+		_ = conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	}
+	for _, expected := range []string{"slapperx.users.requests:3|c", "slapperx.users.errors:1|c", "slapperx.users.rate:3|g",
+		"slapperx.item_1_.requests:1|c", "slapperx.users.latency.max:2000|g", "slapperx_set_rate:50|g"} {
+		if !strings.Contains(received.String(), expected+"\n") {
+			t.Errorf("Expected %q in:\n%s", expected, received.String())
+		}
+	}
+}
+
+func TestInflux(t *testing.T) {
+	receiver, url := startHTTPReceiver(t, http.StatusNoContent)
+	if err := NewInflux(url+"/write?db=test", "secret").Push(newTestAggregate()); err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+	body := receiver.bodies[0]
+	for _, expected := range []string{
+		`slapperx,name=item\ "1" requests=1i,errors=1i,rate=1,`,
+		"slapperx,name=users requests=3i,errors=1i,rate=3,error_rate=0.3333333333333333,",
+		"latency_max_ms=2000 1714564801000000000\n",
+		"slapperx_run set_rate=50 1714564801000000000\n",
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("Expected %q in:\n%s", expected, body)
+		}
+	}
+	if receiver.header.Get("Authorization") != "Token secret" {
+		t.Errorf("Expected token, got %q", receiver.header.Get("Authorization"))
+	}
+
+	_, failingURL := startHTTPReceiver(t, http.StatusBadRequest)
+	if err := NewInflux(failingURL, "").Push(newTestAggregate()); err == nil {
+		t.Errorf("Expected error for status 400")
+	}
+}
+
+func TestOTLP(t *testing.T) {
+	receiver, url := startHTTPReceiver(t, http.StatusOK)
+	if err := NewOTLP(url + "/v1/metrics").Push(newTestAggregate()); err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+	var request otlpRequest
+	if err := json.Unmarshal([]byte(receiver.bodies[0]), &request); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if receiver.header.Get("Content-Type") != "application/json" {
+		t.Errorf("Unexpected content type %q", receiver.header.Get("Content-Type"))
+	}
+	metrics := map[string]otlpMetric{}
+	for _, m := range request.ResourceMetrics[0].ScopeMetrics[0].Metrics {
+		metrics[m.Name] = m
+	}
+	requests := metrics["slapperx.requests"]
+	if requests.Sum == nil || requests.Sum.AggregationTemporality != aggregationTemporalityDelta || len(requests.Sum.DataPoints) != 2 {
+		t.Fatalf("Unexpected requests metric %+v", requests)
+	}
+	point := requests.Sum.DataPoints[1]
+	if point.Attributes[0].Value.StringValue != "users" || point.AsInt != "3" || point.TimeUnixNano != "1714564801000000000" {
+		t.Errorf("Unexpected data point %+v", point)
+	}
+	if p99 := metrics["slapperx.latency.p99"]; p99.Gauge == nil || *p99.Gauge.DataPoints[1].AsDouble != 2000 {
+		t.Errorf("Unexpected p99 metric %+v", p99)
+	}
+	if setRate := metrics["slapperx_set_rate"]; setRate.Gauge == nil || *setRate.Gauge.DataPoints[0].AsDouble != 50 {
+		t.Errorf("Unexpected set rate metric %+v", setRate)
+	}
+}
+
+// countingSink counts the pushed requests
+type countingSink struct {
+	mu       sync.Mutex
+	pushes   int
+	requests int64
+}
+
+func (s *countingSink) Push(a *Aggregate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pushes++
+	for _, e := range a.Endpoints {
+		s.requests += e.Requests
+	}
+	return nil
+}
+
+func TestPusher(t *testing.T) {
+	c := NewCollector()
+	sink := &countingSink{}
+	pusher := NewPusher(c, time.Hour, sink)
+	c.Add(&logformat.Record{Name: "a", Status: 200, ElapsedMs: 1})
+
+	pusher.Tick(time.Now()) // interval not elapsed
+	pusher.Tick(time.Now().Add(2 * time.Hour))
+	c.Add(&logformat.Record{Name: "a", Status: 200, ElapsedMs: 1})
+	pusher.Flush()
+
+	if sink.pushes != 2 || sink.requests != 2 {
+		t.Errorf("Expected 2 pushes with 2 requests, got %d pushes with %d requests", sink.pushes, sink.requests)
+	}
+	if failed, _ := pusher.Errors(); failed != 0 {
+		t.Errorf("Expected no errors, got %d", failed)
+	}
+}
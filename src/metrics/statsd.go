@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+)
+
+// maxStatsDPacket keeps the UDP packets below the usual MTU
+const maxStatsDPacket = 1400
+
+// StatsD pushes the aggregates as counters and gauges to a StatsD server via UDP
+type StatsD struct {
+	conn net.Conn
+}
+
+func NewStatsD(addr string) (*StatsD, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &StatsD{conn: conn}, nil
+}
+
+// statsDLines returns the metrics of the aggregate, for example slapperx.users.requests:10|c
+func statsDLines(a *Aggregate) []string {
+	var lines []string
+	for i := range a.Endpoints {
+		e := &a.Endpoints[i]
+		prefix := "slapperx." + sanitizeName(e.Name) + "."
+		lines = append(lines,
+			fmt.Sprintf("%srequests:%d|c", prefix, e.Requests),
+			fmt.Sprintf("%serrors:%d|c", prefix, e.Errors),
+			fmt.Sprintf("%srate:%g|g", prefix, a.Rate(e)),
+			fmt.Sprintf("%slatency.mean:%g|g", prefix, e.Latency.MeanMs),
+			fmt.Sprintf("%slatency.p50:%g|g", prefix, e.Latency.P50Ms),
+			fmt.Sprintf("%slatency.p90:%g|g", prefix, e.Latency.P90Ms),
+			fmt.Sprintf("%slatency.p95:%g|g", prefix, e.Latency.P95Ms),
+			fmt.Sprintf("%slatency.p99:%g|g", prefix, e.Latency.P99Ms),
+			fmt.Sprintf("%slatency.max:%g|g", prefix, e.Latency.MaxMs),
+		)
+	}
+	for _, g := range a.Gauges {
+		lines = append(lines, fmt.Sprintf("%s:%g|g", g.Name, g.Value))
+	}
+	return lines
+}
+
+func (s *StatsD) Push(a *Aggregate) error {
+	var packet bytes.Buffer
+	for _, line := range statsDLines(a) {
+		if packet.Len() > 0 && packet.Len()+1+len(line) > maxStatsDPacket {
+			if _, err := s.conn.Write(packet.Bytes()); err != nil {
+				return err
+			}
+			packet.Reset()
+		}
+		if packet.Len() > 0 {
+			packet.WriteByte('\n')
+		}
+		packet.WriteString(line)
+	}
+	if packet.Len() == 0 {
+		return nil
+	}
+	_, err := s.conn.Write(packet.Bytes())
+	return err
+}
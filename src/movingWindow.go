@@ -1,11 +1,9 @@
 package slapperx
 
-import "time"
-
-type OkBadCounter struct {
-	Ok  int
-	Bad int
-}
+import (
+	"sync"
+	"time"
+)
 
 type windowState int
 
@@ -16,28 +14,31 @@ type OkBadCounter struct {
 
 // ring moving window buffer
 type MovingWindow struct {
-	counts   [][]OkBadCounter
+	mu       sync.Mutex      // guards the counts against a resize of the terminal
+	counts   []map[int][]int // per slot the requests per bucket by status code, 0 for failed requests
 	state    []windowState
 	nwindows int
 	nbuckets int
-	tOk      []int
-	tBad     []int
+	totals   [statusClassCount][]int
+
+	// requests per bucket and second for the heatmap
+	heatmap *Heatmap
 }
 
 func NewMovingWindow(nwindows int, nbuckets int) *MovingWindow {
 	mw := &MovingWindow{
 		nwindows: nwindows,
 		nbuckets: nbuckets,
-		tOk:      make([]int, nbuckets),
-		tBad:     make([]int, nbuckets),
+		heatmap:  NewHeatmap(nbuckets),
 	}
 	mw.state = make([]windowState, nwindows)
 
-	mw.counts = make([][]OkBadCounter, nwindows)
+	mw.counts = make([]map[int][]int, nwindows)
 	for i := 0; i < nwindows; i++ {
 		mw.state[i] = Filled
-		mw.counts[i] = make([]OkBadCounter, nbuckets)
+		mw.counts[i] = make(map[int][]int)
 	}
+	mw.allocateTotals()
 	return mw
 }
 
@@ -54,13 +55,17 @@ func (mw *MovingWindow) Listen() chan ResultStruct {
 		for {
 			select {
 			case result := <-resultChan:
+				mw.mu.Lock()
 				elapsedBucket := ui.lbc.calculateBucket(float64(result.elapsedMs))
 				slot := mw.getTimingsSlot(result.end) // end is basically now
-				if result.status >= 200 && result.status < 300 {
-					mw.counts[slot][elapsedBucket].Ok++
-				} else {
-					mw.counts[slot][elapsedBucket].Bad++
+				buckets, ok := mw.counts[slot][result.status]
+				if !ok {
+					buckets = make([]int, mw.nbuckets)
+					mw.counts[slot][result.status] = buckets
 				}
+				buckets[elapsedBucket]++
+				mw.heatmap.add(result.end, elapsedBucket)
+				mw.mu.Unlock()
 
 			}
 		}
@@ -80,46 +85,90 @@ func (mw *MovingWindow) getTimingsSlot(now time.Time) int {
 }
 
 func (mw *MovingWindow) Reset() {
+	mw.mu.Lock()
+	defer mw.mu.Unlock()
 	if mw.counts == nil {
 		return
 	}
-	for _, e := range mw.counts {
-		for j := 0; j < len(e); j++ {
-			e[j].Ok = 0
-			e[j].Bad = 0
-		}
+	for _, slot := range mw.counts {
+		clear(slot)
 	}
+	mw.heatmap.reset()
 }
 
+// ResetSlot clears the counts of a slot, the bucket slices are reused
 func (mw *MovingWindow) ResetSlot(slot int) {
-	buckets := mw.counts[slot]
-	for i := 0; i < len(buckets); i++ {
-		buckets[i].Ok = 0
-		buckets[i].Bad = 0
+	for _, buckets := range mw.counts[slot] {
+		clear(buckets)
 	}
 	mw.state[slot] = Ready
 }
 
-// prepareHistogramData prepares data for histogram by aggregating OK and Bad requests
-func (mw *MovingWindow) prepareHistogramData() ([]int, []int, int) {
-	for j := range mw.nbuckets {
-		mw.tOk[j] = 0
-		mw.tBad[j] = 0
+func (mw *MovingWindow) allocateTotals() {
+	for class := range mw.totals {
+		mw.totals[class] = make([]int, mw.nbuckets)
 	}
+}
 
-	maximum := 1
+// prepareHistogramData sums the requests of all slots per status class and bucket. Only the requests
+// matching the filter are counted. The maximum is the largest sum of the classes of one bucket.
+func (mw *MovingWindow) prepareHistogramData(filter statusFilter) ([statusClassCount][]int, int) {
+	mw.mu.Lock()
+	defer mw.mu.Unlock()
+	for class := range mw.totals {
+		clear(mw.totals[class])
+	}
 
 	for i := 0; i < mw.nwindows; i++ {
-		okBad := mw.counts[i]
-
-		for j := 0; j < mw.nbuckets; j++ {
-			mw.tOk[j] += okBad[j].Ok
-			mw.tBad[j] += okBad[j].Bad
-			if sum := mw.tOk[j] + mw.tBad[j]; sum > maximum {
-				maximum = sum
+		for status, buckets := range mw.counts[i] {
+			if !filter.matches(status) {
+				continue
 			}
+			totals := mw.totals[classOf(status)]
+			for j, count := range buckets {
+				totals[j] += count
+			}
+		}
+	}
+
+	maximum := 1
+	for j := 0; j < mw.nbuckets; j++ {
+		sum := 0
+		for class := range mw.totals {
+			sum += mw.totals[class][j]
 		}
+		maximum = max(maximum, sum)
 	}
+	return mw.totals, maximum
+}
+
+// Rebucket changes the bucket calculator with update and moves the counts of every old bucket
+// to the new bucket which contains its center, so the histogram keeps its data
+func (mw *MovingWindow) Rebucket(lbc *logBucketCalculator, update func()) {
+	mw.mu.Lock()
+	defer mw.mu.Unlock()
 
-	return mw.tOk, mw.tBad, maximum
+	centers := make([]float64, lbc.buckets)
+	for bkt := range centers {
+		centers[bkt] = lbc.bucketCenter(bkt)
+	}
+	update()
+	nbuckets := lbc.buckets
+	mapping := make([]int, len(centers))
+	for bkt, center := range centers {
+		mapping[bkt] = lbc.calculateBucket(center)
+	}
+
+	for _, slot := range mw.counts {
+		for status, buckets := range slot {
+			counts := make([]int, nbuckets)
+			for bkt, c := range buckets {
+				counts[mapping[bkt]] += c
+			}
+			slot[status] = counts
+		}
+	}
+	mw.heatmap.resize(mapping, nbuckets)
+	mw.nbuckets = nbuckets
+	mw.allocateTotals()
 }
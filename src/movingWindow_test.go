@@ -0,0 +1,95 @@
+package slapperx
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRebucket(t *testing.T) {
+	tests := []struct {
+		name   string
+		update func(lbc *logBucketCalculator)
+		// bucket of the new calculator for each old bucket
+		expected []int
+	}{
+		{"unchanged", func(lbc *logBucketCalculator) {}, []int{0, 1, 2, 3, 4, 5, 6}},
+		{"zoom out", func(lbc *logBucketCalculator) { lbc.zoom(2) }, []int{0, 1, 1, 2, 2, 3, 4}},
+		{"pan", func(lbc *logBucketCalculator) { lbc.pan(0.4) }, []int{0, 0, 0, 1, 2, 3, 6}},
+		{"more buckets", func(lbc *logBucketCalculator) { lbc.updateBucket(12) }, []int{0, 2, 3, 5, 7, 9, 11}},
+	}
+	end := time.Unix(1000, 0)
+	for _, test := range tests {
+		// linear edges 0, 20, 40, 60, 80, 100 ms
+		lbc := newLogBucketCalculator(0, 100*time.Millisecond, 7)
+		lbc.setScale(scaleLinear, 7)
+		mw := NewMovingWindow(2, lbc.buckets)
+		counts := make([]int, lbc.buckets)
+		for bkt := range counts {
+			counts[bkt] = bkt + 1
+			mw.heatmap.add(end, bkt)
+		}
+		mw.counts[0][200] = counts
+
+		mw.Rebucket(lbc, func() { test.update(lbc) })
+
+		if mw.nbuckets != lbc.buckets || len(mw.counts[0][200]) != lbc.buckets {
+			t.Errorf("%s: expected %d buckets, got %d", test.name, lbc.buckets, len(mw.counts[0][200]))
+			continue
+		}
+		expected := make([]int, lbc.buckets)
+		expectedHeat := make([]int, lbc.buckets)
+		for bkt, newBkt := range test.expected {
+			expected[newBkt] += bkt + 1
+			expectedHeat[newBkt]++
+		}
+		columns, _ := mw.heatmapData(end, 1)
+		for bkt := range expected {
+			if mw.counts[0][200][bkt] != expected[bkt] || columns[0][bkt] != expectedHeat[bkt] {
+				t.Errorf("%s: expected counts %v and heatmap %v, got %v and %v",
+					test.name, expected, expectedHeat, mw.counts[0][200], columns[0])
+				break
+			}
+		}
+	}
+}
+
+func TestPrepareHistogramData(t *testing.T) {
+	mw := NewMovingWindow(2, 3)
+	// bucket counts of the statuses in two slots
+	mw.counts[0][200] = []int{1, 2, 0}
+	mw.counts[1][204] = []int{0, 1, 0}
+	mw.counts[0][301] = []int{0, 0, 1}
+	mw.counts[0][404] = []int{2, 0, 0}
+	mw.counts[1][404] = []int{1, 0, 0}
+	mw.counts[1][500] = []int{0, 3, 0}
+	mw.counts[0][0] = []int{0, 0, 4}
+
+	tests := []struct {
+		filter   string
+		expected [statusClassCount][]int // 2xx, 3xx, 4xx, 5xx, error
+		maximum  int
+	}{
+		{"all", [statusClassCount][]int{{1, 3, 0}, {0, 0, 1}, {3, 0, 0}, {0, 3, 0}, {0, 0, 4}}, 6},
+		{"2xx", [statusClassCount][]int{{1, 3, 0}, {0, 0, 0}, {0, 0, 0}, {0, 0, 0}, {0, 0, 0}}, 3},
+		{"4xx", [statusClassCount][]int{{0, 0, 0}, {0, 0, 0}, {3, 0, 0}, {0, 0, 0}, {0, 0, 0}}, 3},
+		{"error", [statusClassCount][]int{{0, 0, 0}, {0, 0, 0}, {0, 0, 0}, {0, 0, 0}, {0, 0, 4}}, 4},
+		{"204", [statusClassCount][]int{{0, 1, 0}, {0, 0, 0}, {0, 0, 0}, {0, 0, 0}, {0, 0, 0}}, 1},
+		{"502", [statusClassCount][]int{{0, 0, 0}, {0, 0, 0}, {0, 0, 0}, {0, 0, 0}, {0, 0, 0}}, 1},
+	}
+	for _, test := range tests {
+		filter, err := parseStatusFilter(test.filter)
+		if err != nil {
+			t.Fatalf("%q: unexpected error %v", test.filter, err)
+		}
+		totals, maximum := mw.prepareHistogramData(filter)
+		if maximum != test.maximum {
+			t.Errorf("%q: expected maximum %d, got %d", test.filter, test.maximum, maximum)
+		}
+		for class := range totals {
+			if !slices.Equal(totals[class], test.expected[class]) {
+				t.Errorf("%q: expected %v for class %v, got %v", test.filter, test.expected[class], statusClass(class), totals[class])
+			}
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package slapperx
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Profile is a named load level which can be switched to at runtime
+type Profile struct {
+	Name   string
+	Rate   float64
+	RampUp time.Duration // time to reach the rate from the current set rate
+}
+
+// parseProfiles parses profiles in the form name=rate[,rampup], e.g. peak=500,30s
+func parseProfiles(exprs []string) (map[string]Profile, error) {
+	profiles := make(map[string]Profile, len(exprs))
+	for _, expr := range exprs {
+		name, value, ok := strings.Cut(expr, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid profile %q, expected name=rate[,rampup]", expr)
+		}
+		if _, exists := profiles[name]; exists {
+			return nil, fmt.Errorf("duplicate profile %q", name)
+		}
+		profile := Profile{Name: name}
+		rate, rampUp, hasRampUp := strings.Cut(value, ",")
+		var err error
+		profile.Rate, err = strconv.ParseFloat(rate, 64)
+		if err != nil || !(profile.Rate > 0 && profile.Rate <= maxSetRate) {
+			return nil, fmt.Errorf("invalid rate in profile %q", expr)
+		}
+		if hasRampUp {
+			profile.RampUp, err = time.ParseDuration(rampUp)
+			if err != nil || profile.RampUp < 0 {
+				return nil, fmt.Errorf("invalid ramp up time in profile %q", expr)
+			}
+		}
+		profiles[name] = profile
+	}
+	return profiles, nil
+}
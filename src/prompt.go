@@ -0,0 +1,80 @@
+package slapperx
+
+import (
+	term "github.com/nsf/termbox-go"
+	"sync"
+)
+
+// Prompt reads a command from the keyboard, like the command line of vi. While it is open
+// all keys go to the prompt. Enter executes the command, Esc cancels it.
+type Prompt struct {
+	mu      sync.Mutex
+	open    bool
+	line    []rune
+	message string // result of the last command
+	failed  bool   // the last command returned an error
+	execute func(command string) (string, error)
+}
+
+func NewPrompt(execute func(command string) (string, error)) *Prompt {
+	return &Prompt{execute: execute}
+}
+
+// Open starts reading a new command
+func (p *Prompt) Open() {
+	p.OpenWith("")
+}
+
+// OpenWith starts reading a new command, which begins with the given text
+func (p *Prompt) OpenWith(text string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.open = true
+	p.line = append(p.line[:0], []rune(text)...)
+}
+
+func (p *Prompt) IsOpen() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.open
+}
+
+// handleKey edits the command line
+func (p *Prompt) handleKey(ev term.Event) {
+	p.mu.Lock()
+	switch {
+	case ev.Key == term.KeyEsc || ev.Key == term.KeyCtrlC:
+		p.open = false
+	case ev.Key == term.KeyBackspace || ev.Key == term.KeyBackspace2:
+		if len(p.line) > 0 {
+			p.line = p.line[:len(p.line)-1]
+		} else {
+			p.open = false
+		}
+	case ev.Key == term.KeySpace:
+		p.line = append(p.line, ' ')
+	case ev.Key == term.KeyEnter:
+		p.open = false
+		command := string(p.line)
+		p.mu.Unlock()
+		message, err := p.execute(command)
+		p.mu.Lock()
+		p.message, p.failed = message, err != nil
+		if err != nil {
+			p.message = err.Error()
+		}
+	case ev.Ch != 0:
+		p.line = append(p.line, ev.Ch)
+	}
+	p.mu.Unlock()
+}
+
+// Status returns the command line while the prompt is open and the result of the last command otherwise
+func (p *Prompt) Status() (text string, failed bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.open {
+		return ":" + string(p.line), false
+	}
+	return p.message, p.failed
+}
@@ -0,0 +1,67 @@
+package slapperx
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	term "github.com/nsf/termbox-go"
+)
+
+// typeKeys returns the events for typing the text
+func typeKeys(text string) []term.Event {
+	var events []term.Event
+	for _, ch := range text {
+		if ch == ' ' {
+			events = append(events, term.Event{Key: term.KeySpace})
+		} else {
+			events = append(events, term.Event{Ch: ch})
+		}
+	}
+	return events
+}
+
+func TestPromptHandleKey(t *testing.T) {
+	backspace := term.Event{Key: term.KeyBackspace2}
+	enter := term.Event{Key: term.KeyEnter}
+	tests := []struct {
+		name     string
+		start    string
+		keys     []term.Event
+		open     bool
+		status   string
+		failed   bool
+		executed []string
+	}{
+		{"typing", "", typeKeys("rate 5"), true, ":rate 5", false, nil},
+		{"backspace removes a character", "rate", []term.Event{backspace}, true, ":rat", false, nil},
+		{"backspace on empty closes", "", []term.Event{backspace}, false, "", false, nil},
+		{"backspace until empty closes", "r", []term.Event{backspace, backspace}, false, "", false, nil},
+		{"escape cancels", "rate 5", []term.Event{{Key: term.KeyEsc}}, false, "", false, nil},
+		{"enter runs the command", "", append(typeKeys("rate 5"), enter), false, "ok: rate 5", false, []string{"rate 5"}},
+		{"enter shows the error", "fail", []term.Event{enter}, false, "failed: fail", true, []string{"fail"}},
+		{"open with text", "filter ", typeKeys("4xx"), true, ":filter 4xx", false, nil},
+	}
+	for _, test := range tests {
+		var executed []string
+		p := NewPrompt(func(command string) (string, error) {
+			executed = append(executed, command)
+			if command == "fail" {
+				return "", errors.New("failed: " + command)
+			}
+			return "ok: " + command, nil
+		})
+		p.OpenWith(test.start)
+		for _, ev := range test.keys {
+			p.handleKey(ev)
+		}
+		status, failed := p.Status()
+		if p.IsOpen() != test.open || status != test.status || failed != test.failed {
+			t.Errorf("%s: expected open %v, status %q, failed %v, got %v, %q, %v",
+				test.name, test.open, test.status, test.failed, p.IsOpen(), status, failed)
+		}
+		if !slices.Equal(executed, test.executed) {
+			t.Errorf("%s: expected commands %q, got %q", test.name, test.executed, executed)
+		}
+	}
+}
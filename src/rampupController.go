@@ -1,7 +1,9 @@
 package slapperx
 
 import (
+	"fmt"
 	"math"
+	"sync"
 	"time"
 )
 
@@ -9,11 +11,29 @@
 const (
 	rateIncreaseStep = 10
 	rateDecreaseStep = -10
+
+	minSetRate = 0.0001
+	maxSetRate = 1e6 // higher rates overflow the tick duration of the ticker
 )
 
+// clampRate limits the rate to the range the ticker supports
+func clampRate(rate float64) float64 {
+	return math.Min(math.Max(rate, minSetRate), maxSetRate)
+}
+
+// checkRate returns an error if the rate is not finite, not positive or above maxSetRate
+func checkRate(rate float64) error {
+	if !(rate > 0 && rate <= maxSetRate) {
+		return fmt.Errorf("the rate must be above 0 and at most %g", float64(maxSetRate))
+	}
+	return nil
+}
+
 type RampUpController struct {
+	mu              sync.Mutex
 	startTime       time.Time
 	rampUpTime      time.Duration
+	startRate       float64 // rate at the start of the ramp
 	maxRate         float64
 	rateChangerChan chan float64
 }
@@ -33,35 +53,44 @@ func (r *RampUpController) rateChangeListener() {
 	for {
 		select {
 		case rateChange := <-r.rateChangerChan:
-			r.maxRate += rateChange
-			r.maxRate = math.Max(0.0001, r.maxRate)
+			r.ChangeRate(rateChange)
 		}
 	}
 }
 
 // StartRampUpProcess starts the ramp-up process.
 func (r *RampUpController) startRampUpTimeProcess(rateChangerChan chan float64) {
+	r.mu.Lock()
 	r.startTime = time.Now()
+	r.mu.Unlock()
 	lastRate := 0.
 	for {
 		now := time.Now()
+		r.mu.Lock()
 		elapsed := now.Sub(r.startTime)
-		if elapsed.Milliseconds() >= r.rampUpTime.Milliseconds() {
-			maxRate := r.maxRate
+		rampUpTime, startRate, maxRate := r.rampUpTime, r.startRate, r.maxRate
+		r.mu.Unlock()
+		if elapsed.Milliseconds() >= rampUpTime.Milliseconds() {
 			if maxRate != lastRate { // only send if rate has changed
 				rateChangerChan <- maxRate
 				lastRate = maxRate
 			}
 		} else {
-			rateChangerChan <- (float64(elapsed.Milliseconds()) * r.maxRate) / float64(r.rampUpTime.Milliseconds())
+			lastRate = startRate + (float64(elapsed.Milliseconds())*(maxRate-startRate))/float64(rampUpTime.Milliseconds())
+			rateChangerChan <- lastRate
 		}
 		time.Sleep(500 * time.Millisecond)
 	}
 }
 
-// ChangeRate allows direct modification of the rate by a delta amount
+// ChangeRate allows direct modification of the rate by a delta amount. A NaN delta is ignored.
 func (r *RampUpController) ChangeRate(delta float64) {
-	r.rateChangerChan <- delta
+	if math.IsNaN(delta) {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.maxRate = clampRate(r.maxRate + delta)
 }
 
 // IncreaseRate increases the rate by the standard step
@@ -74,12 +103,31 @@ func (r *RampUpController) DecreaseRate() {
 	r.ChangeRate(rateDecreaseStep)
 }
 
-// SetRate sets the rate to an absolute value (implemented as a delta from current)
+// SetRate sets the rate to an absolute value. A NaN rate is ignored.
 func (r *RampUpController) SetRate(newRate float64) {
-	// Calculate the delta needed to reach newRate
-	// This needs to be sent through the channel to ensure thread safety
-	delta := newRate - r.maxRate
-	r.ChangeRate(delta)
+	if math.IsNaN(newRate) {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.maxRate = clampRate(newRate)
+}
+
+// TargetRate returns the rate at the end of the ramp
+func (r *RampUpController) TargetRate() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.maxRate
+}
+
+// Ramp changes the rate linearly from the current set rate to newRate within rampUpTime
+func (r *RampUpController) Ramp(newRate float64, rampUpTime time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.startTime = time.Now()
+	r.rampUpTime = rampUpTime
+	r.startRate = stats.getSetRate()
+	r.maxRate = clampRate(newRate)
 }
 
 // GetRateChanger returns a channel for changing the ticker's rate during operation.
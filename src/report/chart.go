@@ -0,0 +1,148 @@
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"math"
+	"strings"
+)
+
+const (
+	chartWidth  = 720
+	chartHeight = 260
+	chartLeft   = 60 // space for the y axis labels
+	chartBottom = 30 // space for the x axis labels
+	chartTop    = 10
+	chartRight  = 10
+)
+
+// Series is a line in a line chart
+type Series struct {
+	Name   string
+	Color  string
+	Points [][2]float64 // x, y
+}
+
+// Marker is a vertical line in a line chart, e.g. for an annotation
+type Marker struct {
+	X     float64
+	Label string
+}
+
+// Bar is a single bar in a bar chart
+type Bar struct {
+	Label string
+	Value float64
+}
+
+// niceMax rounds the maximum of an axis up to 1, 2 or 5 times a power of ten
+func niceMax(v float64) float64 {
+	if v <= 0 {
+		return 1
+	}
+	magnitude := math.Pow(10, math.Floor(math.Log10(v)))
+	for _, m := range []float64{1, 2, 5, 10} {
+		if v <= m*magnitude {
+			return m * magnitude
+		}
+	}
+	return 10 * magnitude
+}
+
+func formatValue(v float64) string {
+	if v >= 100 || v == math.Trunc(v) {
+		return fmt.Sprintf("%.0f", v)
+	}
+	return fmt.Sprintf("%.3g", v)
+}
+
+// writeYAxis draws the horizontal grid lines with labels
+func writeYAxis(sb *strings.Builder, yMax float64, unit string) {
+	plotHeight := float64(chartHeight - chartTop - chartBottom)
+	for i := 0; i <= 4; i++ {
+		value := yMax * float64(i) / 4
+		y := float64(chartTop) + plotHeight*(1-float64(i)/4)
+		_, _ = fmt.Fprintf(sb, `<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" class="grid"/>`, chartLeft, y, chartWidth-chartRight, y)
+		_, _ = fmt.Fprintf(sb, `<text x="%d" y="%.1f" class="ylabel">%s%s</text>`, chartLeft-5, y+4, formatValue(value), unit)
+	}
+}
+
+func svgStart(sb *strings.Builder) {
+	_, _ = fmt.Fprintf(sb, `<svg viewBox="0 0 %d %d" class="chart">`, chartWidth, chartHeight)
+}
+
+// LineChart renders the series as SVG with a common x and y axis starting at zero.
+// The markers are drawn as dashed vertical lines with their label as tooltip.
+func LineChart(series []Series, markers []Marker, xUnit string, yUnit string) template.HTML {
+	xMax, yMax := 0., 0.
+	for _, m := range markers {
+		xMax = math.Max(xMax, m.X)
+	}
+	for _, s := range series {
+		for _, p := range s.Points {
+			xMax = math.Max(xMax, p[0])
+			yMax = math.Max(yMax, p[1])
+		}
+	}
+	xMax = niceMax(xMax)
+	yMax = niceMax(yMax)
+	plotWidth := float64(chartWidth - chartLeft - chartRight)
+	plotHeight := float64(chartHeight - chartTop - chartBottom)
+
+	var sb strings.Builder
+	svgStart(&sb)
+	writeYAxis(&sb, yMax, yUnit)
+	for i := 0; i <= 5; i++ {
+		value := xMax * float64(i) / 5
+		x := float64(chartLeft) + plotWidth*float64(i)/5
+		_, _ = fmt.Fprintf(&sb, `<text x="%.1f" y="%d" class="xlabel">%s%s</text>`, x, chartHeight-10, formatValue(value), xUnit)
+	}
+	for _, m := range markers {
+		x := float64(chartLeft) + plotWidth*m.X/xMax
+		_, _ = fmt.Fprintf(&sb, `<line x1="%.1f" y1="%d" x2="%.1f" y2="%d" class="marker"><title>%s</title></line>`,
+			x, chartTop, x, chartHeight-chartBottom, template.HTMLEscapeString(m.Label))
+	}
+	for _, s := range series {
+		points := make([]string, len(s.Points))
+		for i, p := range s.Points {
+			x := float64(chartLeft) + plotWidth*p[0]/xMax
+			y := float64(chartTop) + plotHeight*(1-p[1]/yMax)
+			points[i] = fmt.Sprintf("%.1f,%.1f", x, y)
+		}
+		_, _ = fmt.Fprintf(&sb, `<polyline points="%s" stroke="%s" class="line"><title>%s</title></polyline>`,
+			strings.Join(points, " "), s.Color, template.HTMLEscapeString(s.Name))
+	}
+	sb.WriteString(`</svg>`)
+	return template.HTML(sb.String())
+}
+
+// BarChart renders the bars as SVG with a label below every bar
+func BarChart(bars []Bar, color string, yUnit string) template.HTML {
+	yMax := 0.
+	for _, b := range bars {
+		yMax = math.Max(yMax, b.Value)
+	}
+	yMax = niceMax(yMax)
+	plotWidth := float64(chartWidth - chartLeft - chartRight)
+	plotHeight := float64(chartHeight - chartTop - chartBottom)
+	barWidth := plotWidth / math.Max(float64(len(bars)), 1)
+	// show at most about 12 labels
+	labelEvery := len(bars)/12 + 1
+
+	var sb strings.Builder
+	svgStart(&sb)
+	writeYAxis(&sb, yMax, yUnit)
+	for i, b := range bars {
+		height := plotHeight * b.Value / yMax
+		x := float64(chartLeft) + barWidth*float64(i)
+		_, _ = fmt.Fprintf(&sb, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"><title>%s: %s</title></rect>`,
+			x+1, float64(chartTop)+plotHeight-height, math.Max(barWidth-2, 1), height, color,
+			template.HTMLEscapeString(b.Label), formatValue(b.Value))
+		if i%labelEvery == 0 {
+			_, _ = fmt.Fprintf(&sb, `<text x="%.1f" y="%d" class="xlabel">%s</text>`,
+				x+barWidth/2, chartHeight-10, template.HTMLEscapeString(b.Label))
+		}
+	}
+	sb.WriteString(`</svg>`)
+	return template.HTML(sb.String())
+}
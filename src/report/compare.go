@@ -0,0 +1,172 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+// Tolerance defines how much worse the candidate may be than the baseline
+type Tolerance struct {
+	Latency    float64 // allowed relative increase of the latency percentiles
+	Throughput float64 // allowed relative decrease of the throughput
+	ErrorRate  float64 // allowed absolute increase of the error rate
+}
+
+// MetricComparison compares one metric of baseline and candidate
+type MetricComparison struct {
+	Metric    string  `json:"metric"`
+	Baseline  float64 `json:"baseline"`
+	Candidate float64 `json:"candidate"`
+	Change    float64 `json:"change"` // relative change, absolute change for the error rate
+	Regressed bool    `json:"regressed"`
+}
+
+// EndpointComparison contains the compared metrics of one endpoint or of the whole run
+type EndpointComparison struct {
+	Name      string             `json:"name"`
+	Missing   string             `json:"missing,omitempty"` // "baseline" or "candidate" if only one run contains the endpoint
+	Metrics   []MetricComparison `json:"metrics,omitempty"`
+	Regressed bool               `json:"regressed"`
+}
+
+// Comparison is the result of comparing a candidate run against a baseline run
+type Comparison struct {
+	Tolerance Tolerance            `json:"tolerance"`
+	Endpoints []EndpointComparison `json:"endpoints"` // the first entry is the whole run
+	Regressed bool                 `json:"regressed"`
+}
+
+// overallName is the name of the whole run in the comparison
+const overallName = "overall"
+
+// Compare compares throughput, error rate and latency percentiles per endpoint and overall
+func Compare(baseline, candidate *RunSummary, tolerance Tolerance) *Comparison {
+	c := &Comparison{Tolerance: tolerance}
+	c.add(compareEndpoint(overallName, &baseline.Overall, &candidate.Overall, tolerance))
+
+	candidates := make(map[string]*EndpointSummary)
+	for i := range candidate.Endpoints {
+		candidates[candidate.Endpoints[i].Name] = &candidate.Endpoints[i]
+	}
+	for i := range baseline.Endpoints {
+		b := &baseline.Endpoints[i]
+		if cand, ok := candidates[b.Name]; ok {
+			c.add(compareEndpoint(b.Name, b, cand, tolerance))
+			delete(candidates, b.Name)
+		} else {
+			c.add(EndpointComparison{Name: b.Name, Missing: "candidate"})
+		}
+	}
+	for _, cand := range candidate.Endpoints {
+		if _, ok := candidates[cand.Name]; ok {
+			c.add(EndpointComparison{Name: cand.Name, Missing: "baseline"})
+		}
+	}
+	return c
+}
+
+func (c *Comparison) add(e EndpointComparison) {
+	c.Endpoints = append(c.Endpoints, e)
+	c.Regressed = c.Regressed || e.Regressed
+}
+
+func compareEndpoint(name string, baseline, candidate *EndpointSummary, tolerance Tolerance) EndpointComparison {
+	e := EndpointComparison{Name: name}
+	e.Metrics = []MetricComparison{
+		compareHigherIsBetter("throughput", baseline.Throughput, candidate.Throughput, tolerance.Throughput),
+		{
+			Metric:    "error_rate",
+			Baseline:  baseline.ErrorRate,
+			Candidate: candidate.ErrorRate,
+			Change:    candidate.ErrorRate - baseline.ErrorRate,
+			Regressed: candidate.ErrorRate > baseline.ErrorRate+tolerance.ErrorRate,
+		},
+		compareLowerIsBetter("p50_ms", baseline.Latency.P50Ms, candidate.Latency.P50Ms, tolerance.Latency),
+		compareLowerIsBetter("p90_ms", baseline.Latency.P90Ms, candidate.Latency.P90Ms, tolerance.Latency),
+		compareLowerIsBetter("p95_ms", baseline.Latency.P95Ms, candidate.Latency.P95Ms, tolerance.Latency),
+		compareLowerIsBetter("p99_ms", baseline.Latency.P99Ms, candidate.Latency.P99Ms, tolerance.Latency),
+	}
+	for _, m := range e.Metrics {
+		e.Regressed = e.Regressed || m.Regressed
+	}
+	return e
+}
+
+func relativeChange(baseline, candidate float64) float64 {
+	if baseline == 0 {
+		return 0
+	}
+	return (candidate - baseline) / baseline
+}
+
+func compareHigherIsBetter(metric string, baseline, candidate, tolerance float64) MetricComparison {
+	return MetricComparison{
+		Metric:    metric,
+		Baseline:  baseline,
+		Candidate: candidate,
+		Change:    relativeChange(baseline, candidate),
+		Regressed: candidate < baseline*(1-tolerance),
+	}
+}
+
+func compareLowerIsBetter(metric string, baseline, candidate, tolerance float64) MetricComparison {
+	return MetricComparison{
+		Metric:    metric,
+		Baseline:  baseline,
+		Candidate: candidate,
+		Change:    relativeChange(baseline, candidate),
+		Regressed: candidate > baseline*(1+tolerance),
+	}
+}
+
+// WriteComparisonJSON writes the comparison as indented JSON
+func WriteComparisonJSON(w io.Writer, c *Comparison) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(c)
+}
+
+// WriteComparisonText writes the comparison as human readable table
+func WriteComparisonText(w io.Writer, c *Comparison) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "Endpoint\tMetric\tBaseline\tCandidate\tChange\t\n")
+	for _, e := range c.Endpoints {
+		if e.Missing != "" {
+			_, _ = fmt.Fprintf(tw, "%s\t\t\t\tmissing in %s\t\n", e.Name, e.Missing)
+			continue
+		}
+		for _, m := range e.Metrics {
+			change := fmt.Sprintf("%+.1f%%", m.Change*100)
+			if m.Metric == "error_rate" {
+				change = fmt.Sprintf("%+.2f pp", m.Change*100)
+			}
+			if m.Regressed {
+				change += "  REGRESSION"
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t\n", e.Name, m.Metric,
+				formatMetric(m.Metric, m.Baseline), formatMetric(m.Metric, m.Candidate), change)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if c.Regressed {
+		_, _ = fmt.Fprintf(w, "\nThe candidate regressed.\n")
+	} else {
+		_, _ = fmt.Fprintf(w, "\nNo regression.\n")
+	}
+	return nil
+}
+
+func formatMetric(metric string, value float64) string {
+	switch metric {
+	case "throughput":
+		return fmt.Sprintf("%.1f RPS", value)
+	case "error_rate":
+		return fmt.Sprintf("%.2f%%", value*100)
+	default:
+		return fmt.Sprintf("%.1fms", value)
+	}
+}
@@ -0,0 +1,70 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func testSummary(throughput, errorRate, p99 float64, endpoints ...string) *RunSummary {
+	endpoint := EndpointSummary{
+		Requests:   1000,
+		Throughput: throughput,
+		ErrorRate:  errorRate,
+		Latency:    LatencySummary{P50Ms: 10, P90Ms: 20, P95Ms: 30, P99Ms: p99},
+	}
+	s := &RunSummary{Version: SummaryVersion, Overall: endpoint}
+	for _, name := range endpoints {
+		endpoint.Name = name
+		s.Endpoints = append(s.Endpoints, endpoint)
+	}
+	return s
+}
+
+func TestCompare(t *testing.T) {
+	tolerance := Tolerance{Latency: 0.1, Throughput: 0.1, ErrorRate: 0.01}
+	tests := []struct {
+		name      string
+		candidate *RunSummary
+		regressed bool
+	}{
+		{"equal", testSummary(100, 0.01, 50, "a"), false},
+		{"within tolerance", testSummary(91, 0.019, 55, "a"), false},
+		{"throughput", testSummary(89, 0.01, 50, "a"), true},
+		{"error rate", testSummary(100, 0.021, 50, "a"), true},
+		{"latency", testSummary(100, 0.01, 56, "a"), true},
+		{"better", testSummary(200, 0, 10, "a"), false},
+	}
+	baseline := testSummary(100, 0.01, 50, "a")
+	for _, tt := range tests {
+		c := Compare(baseline, tt.candidate, tolerance)
+		if c.Regressed != tt.regressed {
+			t.Errorf("%s: expected regressed %v, got %v", tt.name, tt.regressed, c.Regressed)
+		}
+		if len(c.Endpoints) != 2 || c.Endpoints[0].Name != "overall" || c.Endpoints[1].Name != "a" {
+			t.Errorf("%s: unexpected endpoints %+v", tt.name, c.Endpoints)
+		}
+	}
+}
+
+func TestCompareMissingEndpoints(t *testing.T) {
+	c := Compare(testSummary(100, 0, 50, "a", "b"), testSummary(100, 0, 50, "b", "c"), Tolerance{})
+	if c.Regressed {
+		t.Errorf("Missing endpoints must not count as regression")
+	}
+	missing := map[string]string{}
+	for _, e := range c.Endpoints {
+		missing[e.Name] = e.Missing
+	}
+	if missing["a"] != "candidate" || missing["b"] != "" || missing["c"] != "baseline" {
+		t.Errorf("Unexpected missing endpoints %v", missing)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteComparisonText(&buf, c); err != nil {
+		t.Fatalf("Failed to write comparison: %v", err)
+	}
+	if !strings.Contains(buf.String(), "missing in candidate") || !strings.Contains(buf.String(), "No regression.") {
+		t.Errorf("Unexpected comparison text:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,107 @@
+package report
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+)
+
+//go:embed report.html
+var htmlTemplate string
+
+var htmlReport = template.Must(template.New("report").Funcs(template.FuncMap{
+	"percent": func(v float64) float64 { return v * 100 },
+}).Parse(htmlTemplate))
+
+// ConfigEntry is a setting of the run shown in the HTML report
+type ConfigEntry struct {
+	Key   string
+	Value string
+}
+
+type htmlData struct {
+	Title  string
+	Report *Report
+	Config []ConfigEntry
+
+	LatencySeries    []Series
+	LatencyChart     template.HTML
+	ThroughputSeries []Series
+	ThroughputChart  template.HTML
+	ErrorChart       template.HTML
+	PercentileChart  template.HTML
+	HistogramChart   template.HTML
+	StatusCodes      []Bar
+	ErrorClasses     []Bar
+}
+
+// WriteHTML writes the report as a single HTML file with inline SVG charts and no external assets
+func WriteHTML(w io.Writer, report *Report, title string, config []ConfigEntry) error {
+	// the report is shared, so credentials in URLs and secret settings are removed
+	redactedReport := *report
+	redactedReport.Targets = make([]Target, len(report.Targets))
+	for i, target := range report.Targets {
+		target.Name = RedactURLs(target.Name)
+		target.URL = RedactURLs(target.URL)
+		redactedReport.Targets[i] = target
+	}
+	redactedConfig := make([]ConfigEntry, len(config))
+	for i, entry := range config {
+		redactedConfig[i] = ConfigEntry{Key: entry.Key, Value: RedactValue(entry.Key, entry.Value)}
+	}
+	data := htmlData{
+		Title:  title,
+		Report: &redactedReport,
+		Config: redactedConfig,
+	}
+
+	p50 := Series{Name: "p50", Color: "#2ca02c"}
+	p90 := Series{Name: "p90", Color: "#ff7f0e"}
+	p99 := Series{Name: "p99", Color: "#d62728"}
+	achieved := Series{Name: "achieved rate", Color: "#1f77b4"}
+	set := Series{Name: "set rate", Color: "#999999"}
+	errorRate := Series{Name: "error rate", Color: "#d62728"}
+	for _, i := range report.Intervals {
+		// the interval is drawn at its center
+		x := i.StartS + report.IntervalS/2
+		if i.Requests > 0 {
+			p50.Points = append(p50.Points, [2]float64{x, i.Latency.P50Ms})
+			p90.Points = append(p90.Points, [2]float64{x, i.Latency.P90Ms})
+			p99.Points = append(p99.Points, [2]float64{x, i.Latency.P99Ms})
+			set.Points = append(set.Points, [2]float64{x, i.SetRate})
+			errorRate.Points = append(errorRate.Points, [2]float64{x, float64(i.Errors) * 100 / float64(i.Requests)})
+		}
+		achieved.Points = append(achieved.Points, [2]float64{x, i.AchievedRate})
+	}
+	markers := make([]Marker, len(report.Annotations))
+	for i, a := range report.Annotations {
+		markers[i] = Marker{X: a.OffsetS, Label: a.Text}
+	}
+	data.LatencySeries = []Series{p50, p90, p99}
+	data.LatencyChart = LineChart(data.LatencySeries, markers, "s", "ms")
+	data.ThroughputSeries = []Series{set, achieved}
+	data.ThroughputChart = LineChart(data.ThroughputSeries, markers, "s", "")
+	data.ErrorChart = LineChart([]Series{errorRate}, markers, "s", "%")
+
+	percentiles := make([]Bar, len(report.Percentiles))
+	for i, p := range report.Percentiles {
+		percentiles[i] = Bar{Label: "p" + formatValue(p.Percentile), Value: p.Ms}
+	}
+	data.PercentileChart = BarChart(percentiles, "#ff7f0e", "ms")
+
+	histogram := make([]Bar, len(report.Histogram))
+	for i, b := range report.Histogram {
+		histogram[i] = Bar{Label: fmt.Sprintf("%.3g", b.UpperMs), Value: float64(b.Count)}
+	}
+	data.HistogramChart = BarChart(histogram, "#1f77b4", "")
+
+	for _, status := range sortedKeys(report.StatusCodes) {
+		data.StatusCodes = append(data.StatusCodes, Bar{Label: status, Value: float64(report.StatusCodes[status])})
+	}
+	for _, class := range sortedKeys(report.ErrorsByClass) {
+		data.ErrorClasses = append(data.ErrorClasses, Bar{Label: class, Value: float64(report.ErrorsByClass[class])})
+	}
+
+	return htmlReport.Execute(w, data)
+}
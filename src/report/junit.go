@@ -0,0 +1,62 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitTestSuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+// WriteJUnit writes the threshold results as JUnit XML test suite with one test case per threshold
+func WriteJUnit(w io.Writer, results []ThresholdResult, start time.Time, duration time.Duration) error {
+	suite := junitTestSuite{
+		Name:      "slapperx",
+		Tests:     len(results),
+		Time:      fmt.Sprintf("%.3f", duration.Seconds()),
+		Timestamp: start.Format("2006-01-02T15:04:05"),
+	}
+	for _, result := range results {
+		testCase := junitTestCase{Name: result.Threshold, ClassName: "slapperx.thresholds", Time: suite.Time}
+		if !result.Passed {
+			suite.Failures++
+			testCase.Failure = &junitFailure{
+				Message: fmt.Sprintf("threshold %s failed", result.Threshold),
+				Text:    fmt.Sprintf("value %.6g, limit %.6g", result.Value, result.Limit),
+			}
+		}
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(suite); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
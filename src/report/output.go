@@ -0,0 +1,85 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+)
+
+// WriteJSON writes the report as indented JSON
+func WriteJSON(w io.Writer, report *Report) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+func formatLatency(l LatencySummary) string {
+	return fmt.Sprintf("min %.1fms  mean %.1fms  p50 %.1fms  p90 %.1fms  p95 %.1fms  p99 %.1fms  max %.1fms",
+		l.MinMs, l.MeanMs, l.P50Ms, l.P90Ms, l.P95Ms, l.P99Ms, l.MaxMs)
+}
+
+// sortedKeys returns the keys of the map sorted numerically if possible
+func sortedKeys(m map[string]int64) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, errA := strconv.Atoi(keys[i])
+		b, errB := strconv.Atoi(keys[j])
+		if errA == nil && errB == nil {
+			return a < b
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// WriteText writes the report as human readable text
+func WriteText(w io.Writer, report *Report) error {
+	s := report.Summary
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', tabwriter.AlignRight)
+
+	_, _ = fmt.Fprintf(w, "Start:      %s\n", report.Start.Format("2006-01-02 15:04:05"))
+	_, _ = fmt.Fprintf(w, "Requests:   %d in %.1fs (%.1f RPS)\n", s.Requests, s.DurationS, s.Throughput)
+	_, _ = fmt.Fprintf(w, "Errors:     %d (%.2f%%)\n", s.Errors, s.ErrorRate*100)
+	_, _ = fmt.Fprintf(w, "Latency:    %s\n", formatLatency(s.Latency))
+
+	_, _ = fmt.Fprintf(w, "\nStatus codes:\n")
+	for _, status := range sortedKeys(report.StatusCodes) {
+		count := report.StatusCodes[status]
+		_, _ = fmt.Fprintf(tw, "\t%s\t%d\t%.2f%%\t\n", status, count, float64(count)*100/float64(max(s.Requests, 1)))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(report.ErrorsByClass) > 0 {
+		_, _ = fmt.Fprintf(w, "\nErrors by class:\n")
+		for _, class := range sortedKeys(report.ErrorsByClass) {
+			_, _ = fmt.Fprintf(tw, "\t%s\t%d\t\n", class, report.ErrorsByClass[class])
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if len(report.Annotations) > 0 {
+		_, _ = fmt.Fprintf(w, "\nAnnotations:\n")
+		for _, a := range report.Annotations {
+			_, _ = fmt.Fprintf(w, "  %8.1fs  %s\n", a.OffsetS, a.Text)
+		}
+	}
+
+	_, _ = fmt.Fprintf(w, "\nIntervals of %gs:\n", report.IntervalS)
+	_, _ = fmt.Fprintf(tw, "time\trequests\trate\tset rate\terrors\tp50 ms\tp90 ms\tp99 ms\tmax ms\t\n")
+	for _, i := range report.Intervals {
+		_, _ = fmt.Fprintf(tw, "%.0fs\t%d\t%.1f\t%.1f\t%d\t%.1f\t%.1f\t%.1f\t%.1f\t\n",
+			i.StartS, i.Requests, i.AchievedRate, i.SetRate, i.Errors,
+			i.Latency.P50Ms, i.Latency.P90Ms, i.Latency.P99Ms, i.Latency.MaxMs)
+	}
+	return tw.Flush()
+}
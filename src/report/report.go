@@ -0,0 +1,283 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/s-macke/slapperx/src/logformat"
+)
+
+// LatencySummary contains latency statistics in milliseconds
+type LatencySummary struct {
+	MinMs  float64 `json:"min_ms"`
+	MeanMs float64 `json:"mean_ms"`
+	P50Ms  float64 `json:"p50_ms"`
+	P90Ms  float64 `json:"p90_ms"`
+	P95Ms  float64 `json:"p95_ms"`
+	P99Ms  float64 `json:"p99_ms"`
+	MaxMs  float64 `json:"max_ms"`
+}
+
+// Summary contains the totals of a run
+type Summary struct {
+	Requests   int64          `json:"requests"`
+	Errors     int64          `json:"errors"`     // failed requests and responses with status >= 400
+	ErrorRate  float64        `json:"error_rate"` // fraction of Errors in Requests
+	DurationS  float64        `json:"duration_s"`
+	Throughput float64        `json:"throughput"` // requests per second
+	Latency    LatencySummary `json:"latency"`
+}
+
+// Interval contains the statistics of the requests started in one time slice of the run
+type Interval struct {
+	StartS       float64        `json:"start_s"` // relative to the start of the run
+	Requests     int64          `json:"requests"`
+	Errors       int64          `json:"errors"`
+	AchievedRate float64        `json:"achieved_rate"`
+	SetRate      float64        `json:"set_rate"` // mean set rate of the requests
+	Latency      LatencySummary `json:"latency"`
+}
+
+// Target is a request found in the log file
+type Target struct {
+	Name     string `json:"name"`
+	Method   string `json:"method"`
+	URL      string `json:"url"`
+	Requests int64  `json:"requests"`
+}
+
+// HistogramBucket counts the requests with a latency up to UpperMs
+type HistogramBucket struct {
+	UpperMs float64 `json:"upper_ms"`
+	Count   int64   `json:"count"`
+}
+
+// PercentilePoint is one point of the latency distribution
+type PercentilePoint struct {
+	Percentile float64 `json:"percentile"`
+	Ms         float64 `json:"ms"`
+}
+
+// Annotation is a note taken during the run, e.g. "deploy started"
+type Annotation struct {
+	Time    time.Time `json:"time"`
+	OffsetS float64   `json:"offset_s"` // relative to the start of the run
+	Text    string    `json:"text"`
+}
+
+// distributionPercentiles are the points of the latency distribution in the report
+var distributionPercentiles = []float64{0, 10, 25, 50, 75, 90, 95, 99, 99.9, 99.99, 100}
+
+// maxIntervals limits the intervals of a report, so that a corrupt offset cannot exhaust the memory
+const maxIntervals = 100000
+
+// histogramBucketsPerDecade is the resolution of the logarithmic latency histogram
+const histogramBucketsPerDecade = 5
+
+// Report is the result of the analysis of a log file
+type Report struct {
+	Start         time.Time         `json:"start"`
+	IntervalS     float64           `json:"interval_s"`
+	Summary       Summary           `json:"summary"`
+	StatusCodes   map[string]int64  `json:"status_codes"`
+	ErrorsByClass map[string]int64  `json:"errors_by_class"`
+	Intervals     []Interval        `json:"intervals"`
+	Targets       []Target          `json:"targets"`
+	Histogram     []HistogramBucket `json:"histogram"`
+	Percentiles   []PercentilePoint `json:"percentiles"`
+	Annotations   []Annotation      `json:"annotations"`
+}
+
+// IsError returns true for failed requests and for responses with a client or server error status
+func IsError(r *logformat.Record) bool {
+	return r.Status == 0 || r.Status >= 400
+}
+
+type intervalData struct {
+	requests   int64
+	errors     int64
+	setRateSum float64
+	latencies  []float64
+}
+
+// Analyze reads all records of the log file and aggregates them in intervals of the given length
+func Analyze(decoder *logformat.Decoder, interval time.Duration) (*Report, error) {
+	report := &Report{
+		IntervalS:     interval.Seconds(),
+		StatusCodes:   make(map[string]int64),
+		ErrorsByClass: make(map[string]int64),
+		Annotations:   []Annotation{},
+	}
+	targets := make(map[string]*Target)
+	var targetOrder []string
+	var intervals []*intervalData
+	var latencies []float64
+	var endMs float64
+	intervalMs := logformat.Milliseconds(interval)
+
+	var record logformat.Record
+	for {
+		err := decoder.Decode(&record)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if record.IsAnnotation() {
+			report.Annotations = append(report.Annotations, Annotation{
+				Time:    record.Timestamp,
+				OffsetS: record.OffsetMs / 1000,
+				Text:    record.Annotation,
+			})
+			continue
+		}
+		start := record.Timestamp.Add(-time.Duration(record.OffsetMs * float64(time.Millisecond)))
+		if report.Start.IsZero() || start.Before(report.Start) {
+			report.Start = start
+		}
+		endMs = math.Max(endMs, record.OffsetMs+record.ElapsedMs)
+
+		if !(record.OffsetMs < intervalMs*maxIntervals) {
+			return nil, fmt.Errorf("offset %gms of a request exceeds %d intervals, use a longer interval", record.OffsetMs, maxIntervals)
+		}
+		idx := int(record.OffsetMs / intervalMs)
+		if idx < 0 {
+			idx = 0
+		}
+		for len(intervals) <= idx {
+			intervals = append(intervals, &intervalData{})
+		}
+		data := intervals[idx]
+		data.requests++
+		data.setRateSum += record.SetRate
+		data.latencies = append(data.latencies, record.ElapsedMs)
+		latencies = append(latencies, record.ElapsedMs)
+
+		targetKey := record.Name + " " + record.Method + " " + record.URL
+		target, ok := targets[targetKey]
+		if !ok {
+			target = &Target{Name: record.Name, Method: record.Method, URL: record.URL}
+			targets[targetKey] = target
+			targetOrder = append(targetOrder, targetKey)
+		}
+		target.Requests++
+
+		report.Summary.Requests++
+		report.StatusCodes[strconv.Itoa(record.Status)]++
+		if IsError(&record) {
+			report.Summary.Errors++
+			data.errors++
+		}
+		if record.Status == 0 {
+			class := record.ErrorClass
+			if class == "" {
+				class = "unknown"
+			}
+			report.ErrorsByClass[class]++
+		}
+	}
+
+	summary := &report.Summary
+	summary.DurationS = endMs / 1000
+	if summary.Requests > 0 {
+		summary.ErrorRate = float64(summary.Errors) / float64(summary.Requests)
+	}
+	if summary.DurationS > 0 {
+		summary.Throughput = float64(summary.Requests) / summary.DurationS
+	}
+	summary.Latency = SummarizeLatencies(latencies)
+	report.Histogram = histogram(latencies)
+	report.Percentiles = make([]PercentilePoint, 0, len(distributionPercentiles))
+	if len(latencies) > 0 {
+		for _, p := range distributionPercentiles {
+			report.Percentiles = append(report.Percentiles, PercentilePoint{Percentile: p, Ms: Percentile(latencies, p)})
+		}
+	}
+	report.Targets = make([]Target, len(targetOrder))
+	for i, key := range targetOrder {
+		report.Targets[i] = *targets[key]
+	}
+
+	report.Intervals = make([]Interval, len(intervals))
+	for i, data := range intervals {
+		startS := float64(i) * interval.Seconds()
+		// the last interval usually ends before its full length
+		lengthS := math.Min(interval.Seconds(), summary.DurationS-startS)
+		if lengthS <= 0 {
+			lengthS = interval.Seconds()
+		}
+		report.Intervals[i] = Interval{
+			StartS:       startS,
+			Requests:     data.requests,
+			Errors:       data.errors,
+			AchievedRate: float64(data.requests) / lengthS,
+			Latency:      SummarizeLatencies(data.latencies),
+		}
+		if data.requests > 0 {
+			report.Intervals[i].SetRate = data.setRateSum / float64(data.requests)
+		}
+	}
+	return report, nil
+}
+
+// histogram counts the sorted latencies in logarithmic buckets from the smallest to the largest latency
+func histogram(sorted []float64) []HistogramBucket {
+	if len(sorted) == 0 {
+		return []HistogramBucket{}
+	}
+	step := math.Pow(10, 1./histogramBucketsPerDecade)
+	upper := math.Pow(10, math.Floor(math.Log10(math.Max(sorted[0], 0.001))))
+	var buckets []HistogramBucket
+	idx := 0
+	for idx < len(sorted) {
+		upper *= step
+		bucket := HistogramBucket{UpperMs: upper}
+		for idx < len(sorted) && sorted[idx] <= upper {
+			bucket.Count++
+			idx++
+		}
+		buckets = append(buckets, bucket)
+	}
+	return buckets
+}
+
+// SummarizeLatencies calculates the latency statistics. The slice is sorted in place.
+func SummarizeLatencies(latencies []float64) LatencySummary {
+	if len(latencies) == 0 {
+		return LatencySummary{}
+	}
+	sort.Float64s(latencies)
+	sum := 0.
+	for _, l := range latencies {
+		sum += l
+	}
+	return LatencySummary{
+		MinMs:  latencies[0],
+		MeanMs: sum / float64(len(latencies)),
+		P50Ms:  Percentile(latencies, 50),
+		P90Ms:  Percentile(latencies, 90),
+		P95Ms:  Percentile(latencies, 95),
+		P99Ms:  Percentile(latencies, 99),
+		MaxMs:  latencies[len(latencies)-1],
+	}
+}
+
+// Percentile returns the p-th percentile of the sorted values with the nearest-rank method
+func Percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,190 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/s-macke/slapperx/src/logformat"
+)
+
+func analyzeTestdata(t *testing.T) *Report {
+	file, err := os.Open("testdata/run.csv")
+	if err != nil {
+		t.Fatalf("Failed to open testdata/run.csv: %v", err)
+	}
+	defer file.Close()
+	report, err := Analyze(logformat.NewDecoder(file), time.Second)
+	if err != nil {
+		t.Fatalf("Failed to analyze: %v", err)
+	}
+	return report
+}
+
+func TestAnalyzeSummary(t *testing.T) {
+	report := analyzeTestdata(t)
+	s := report.Summary
+	if s.Requests != 6 || s.Errors != 3 || s.ErrorRate != 0.5 {
+		t.Errorf("Expected 6 requests with 3 errors, got %+v", s)
+	}
+	if s.DurationS != 3 || s.Throughput != 2 {
+		t.Errorf("Expected 3s duration and 2 RPS, got %fs and %f RPS", s.DurationS, s.Throughput)
+	}
+	if s.Latency.MinMs != 10 || s.Latency.MaxMs != 1000 || s.Latency.P50Ms != 30 || s.Latency.P99Ms != 1000 {
+		t.Errorf("Unexpected latency %+v", s.Latency)
+	}
+	if !report.Start.Equal(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected start %s", report.Start)
+	}
+	if report.StatusCodes["200"] != 3 || report.StatusCodes["404"] != 1 || report.StatusCodes["0"] != 2 {
+		t.Errorf("Unexpected status codes %v", report.StatusCodes)
+	}
+	if report.ErrorsByClass["timeout"] != 1 || report.ErrorsByClass["conn_refused"] != 1 || len(report.ErrorsByClass) != 2 {
+		t.Errorf("Unexpected error classes %v", report.ErrorsByClass)
+	}
+}
+
+func TestAnalyzeIntervals(t *testing.T) {
+	report := analyzeTestdata(t)
+	if len(report.Intervals) != 3 {
+		t.Fatalf("Expected 3 intervals, got %d", len(report.Intervals))
+	}
+	first := report.Intervals[0]
+	if first.Requests != 3 || first.Errors != 1 || first.AchievedRate != 3 || first.SetRate != 10 || first.Latency.P50Ms != 20 {
+		t.Errorf("Unexpected first interval %+v", first)
+	}
+	second := report.Intervals[1]
+	if second.StartS != 1 || second.Requests != 2 || second.Errors != 1 || second.SetRate != 20 {
+		t.Errorf("Unexpected second interval %+v", second)
+	}
+}
+
+func TestAnalyzePartialLastInterval(t *testing.T) {
+	file, err := os.Open("testdata/run.csv")
+	if err != nil {
+		t.Fatalf("Failed to open testdata/run.csv: %v", err)
+	}
+	defer file.Close()
+	report, err := Analyze(logformat.NewDecoder(file), 2*time.Second)
+	if err != nil {
+		t.Fatalf("Failed to analyze: %v", err)
+	}
+	if len(report.Intervals) != 2 {
+		t.Fatalf("Expected 2 intervals, got %d", len(report.Intervals))
+	}
+	if rate := report.Intervals[0].AchievedRate; rate != 2.5 {
+		t.Errorf("Expected 2.5 RPS in the first interval, got %f", rate)
+	}
+	// the run ends after 3s, so the last interval only lasts 1s
+	if rate := report.Intervals[1].AchievedRate; rate != 1 {
+		t.Errorf("Expected 1 RPS in the last interval, got %f", rate)
+	}
+}
+
+func TestAnalyzeLegacyLog(t *testing.T) {
+	data := "2024-05-01T12:00:00.1,100,10,200,0,10.0\n2024-05-01T12:00:00.2,200,20,0,1,10.0\n"
+	report, err := Analyze(logformat.NewDecoder(strings.NewReader(data)), time.Second)
+	if err != nil {
+		t.Fatalf("Failed to analyze: %v", err)
+	}
+	if report.Summary.Requests != 2 || report.ErrorsByClass["unknown"] != 1 {
+		t.Errorf("Unexpected report %+v", report)
+	}
+}
+
+func TestAnalyzeAnnotations(t *testing.T) {
+	data := "timestamp,offset_ms,elapsed_ms,status,annotation\n" +
+		"2024-05-01T12:00:00.1Z,100,10,200,\n" +
+		"2024-05-01T12:00:01.5Z,1500,0,0,deploy started\n" +
+		"2024-05-01T12:00:02.1Z,2100,20,200,\n"
+	report, err := Analyze(logformat.NewDecoder(strings.NewReader(data)), time.Second)
+	if err != nil {
+		t.Fatalf("Failed to analyze: %v", err)
+	}
+	if report.Summary.Requests != 2 || report.StatusCodes["0"] != 0 {
+		t.Errorf("Annotations must not be counted as requests, got %+v", report.Summary)
+	}
+	if len(report.Annotations) != 1 || report.Annotations[0].Text != "deploy started" || report.Annotations[0].OffsetS != 1.5 {
+		t.Errorf("Unexpected annotations %+v", report.Annotations)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteText(&buf, report); err != nil || !strings.Contains(buf.String(), "1.5s  deploy started") {
+		t.Errorf("Expected the annotation in the text report, got %v:\n%s", err, buf.String())
+	}
+	buf.Reset()
+	if err := WriteHTML(&buf, report, "run", nil); err != nil || !strings.Contains(buf.String(), `class="marker"><title>deploy started</title>`) {
+		t.Errorf("Expected an annotation marker in the HTML report, got %v", err)
+	}
+}
+
+func TestAnalyzeEmptyAndInvalidLog(t *testing.T) {
+	report, err := Analyze(logformat.NewDecoder(strings.NewReader("")), time.Second)
+	if err != nil {
+		t.Fatalf("Failed to analyze: %v", err)
+	}
+	if report.Summary.Requests != 0 || len(report.Intervals) != 0 {
+		t.Errorf("Expected empty report, got %+v", report)
+	}
+
+	_, err = Analyze(logformat.NewDecoder(strings.NewReader("timestamp\nnot a time\n")), time.Second)
+	if err == nil {
+		t.Errorf("Expected error for invalid log")
+	}
+}
+
+func TestAnalyzeInvalidOffset(t *testing.T) {
+	for _, offset := range []string{"1e12", "NaN", "+Inf"} {
+		data := "2024-05-01T12:00:00.1,100,10,200,0,10.0\n2024-05-01T12:00:00.2," + offset + ",20,200,0,10.0\n"
+		if _, err := Analyze(logformat.NewDecoder(strings.NewReader(data)), time.Second); err == nil {
+			t.Errorf("Expected error for offset %s", offset)
+		}
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	values := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	tests := []struct {
+		p        float64
+		expected float64
+	}{
+		{0, 1}, {10, 1}, {50, 5}, {90, 9}, {99, 10}, {100, 10},
+	}
+	for _, tt := range tests {
+		if v := Percentile(values, tt.p); v != tt.expected {
+			t.Errorf("p%.0f: expected %f, got %f", tt.p, tt.expected, v)
+		}
+	}
+	if Percentile(nil, 50) != 0 {
+		t.Errorf("Expected 0 for empty slice")
+	}
+}
+
+func TestWriteOutput(t *testing.T) {
+	report := analyzeTestdata(t)
+
+	var text bytes.Buffer
+	if err := WriteText(&text, report); err != nil {
+		t.Fatalf("Failed to write text: %v", err)
+	}
+	for _, expected := range []string{"Requests:   6 in 3.0s (2.0 RPS)", "Errors:     3 (50.00%)", "timeout", "Intervals of 1s:"} {
+		if !strings.Contains(text.String(), expected) {
+			t.Errorf("Expected text report to contain %q:\n%s", expected, text.String())
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, report); err != nil {
+		t.Fatalf("Failed to write JSON: %v", err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if decoded.Summary != report.Summary || len(decoded.Intervals) != len(report.Intervals) {
+		t.Errorf("JSON round trip changed the report")
+	}
+}
@@ -0,0 +1,381 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/s-macke/slapperx/src/logformat"
+)
+
+// SummaryVersion is the version of the summary format. It is increased on incompatible changes.
+const SummaryVersion = 1
+
+// EndpointSummary contains the totals of one request or of the whole run
+type EndpointSummary struct {
+	Name       string         `json:"name,omitempty"`
+	Requests   int64          `json:"requests"`
+	Errors     int64          `json:"errors"`
+	ErrorRate  float64        `json:"error_rate"`
+	Throughput float64        `json:"throughput"` // requests per second over the whole run
+	Latency    LatencySummary `json:"latency"`
+}
+
+// RunSummary is the machine-readable summary of a run, used to compare runs
+type RunSummary struct {
+	Version   int               `json:"version"`
+	Start     time.Time         `json:"start"`
+	DurationS float64           `json:"duration_s"`
+	Overall   EndpointSummary   `json:"overall"`
+	Endpoints []EndpointSummary `json:"endpoints"`
+}
+
+// latency range and resolution of the recorder histogram.
+// With 100 buckets per decade a bucket is 2.3% wide, which bounds the error of the interpolated percentiles.
+const (
+	recorderMinMs            = 0.01
+	recorderDecades          = 9
+	recorderBucketsPerDecade = 100
+)
+
+type latencyRecorder struct {
+	requests int64
+	errors   int64
+	sumMs    float64
+	minMs    float64
+	maxMs    float64
+	buckets  []int64
+}
+
+func newLatencyRecorder() *latencyRecorder {
+	return &latencyRecorder{buckets: make([]int64, recorderDecades*recorderBucketsPerDecade+1)}
+}
+
+func (l *latencyRecorder) add(elapsedMs float64, isError bool) {
+	if l.requests == 0 || elapsedMs < l.minMs {
+		l.minMs = elapsedMs
+	}
+	l.maxMs = math.Max(l.maxMs, elapsedMs)
+	l.requests++
+	l.sumMs += elapsedMs
+	if isError {
+		l.errors++
+	}
+	l.buckets[bucketIndex(elapsedMs)]++
+}
+
+func bucketIndex(ms float64) int {
+	idx := 0
+	if ms > recorderMinMs {
+		idx = int(math.Ceil(math.Log10(ms/recorderMinMs) * recorderBucketsPerDecade))
+	}
+	return min(idx, recorderDecades*recorderBucketsPerDecade)
+}
+
+// countAbove returns the number of requests in buckets above the one containing ms
+func (l *latencyRecorder) countAbove(ms float64) int64 {
+	var count int64
+	for _, n := range l.buckets[min(bucketIndex(ms)+1, len(l.buckets)):] {
+		count += n
+	}
+	return count
+}
+
+// bucketUpper returns the upper bound of the bucket in milliseconds
+func bucketUpper(idx int) float64 {
+	return recorderMinMs * math.Pow(10, float64(idx)/recorderBucketsPerDecade)
+}
+
+// percentile estimates the p-th percentile by interpolating within the bucket containing it,
+// assuming the requests are spread evenly over the bucket on the log scale
+func (l *latencyRecorder) percentile(p float64) float64 {
+	rank := max(int64(math.Ceil(p/100*float64(l.requests))), 1)
+	var count int64
+	for idx, n := range l.buckets {
+		if count+n >= rank {
+			upper := math.Min(bucketUpper(idx), l.maxMs)
+			lower := l.minMs
+			if idx > 0 {
+				lower = math.Max(bucketUpper(idx-1), l.minMs)
+			}
+			if lower <= 0 || upper <= lower || rank-count == n {
+				return upper
+			}
+			return lower * math.Pow(upper/lower, float64(rank-count)/float64(n))
+		}
+		count += n
+	}
+	return l.maxMs
+}
+
+func (l *latencyRecorder) summary(name string, durationS float64) EndpointSummary {
+	s := EndpointSummary{
+		Name:     name,
+		Requests: l.requests,
+		Errors:   l.errors,
+	}
+	if l.requests == 0 {
+		return s
+	}
+	s.ErrorRate = float64(l.errors) / float64(l.requests)
+	if durationS > 0 {
+		s.Throughput = float64(l.requests) / durationS
+	}
+	s.Latency = LatencySummary{
+		MinMs:  l.minMs,
+		MeanMs: l.sumMs / float64(l.requests),
+		P50Ms:  l.percentile(50),
+		P90Ms:  l.percentile(90),
+		P95Ms:  l.percentile(95),
+		P99Ms:  l.percentile(99),
+		MaxMs:  l.maxMs,
+	}
+	return s
+}
+
+// merge adds the requests of o
+func (l *latencyRecorder) merge(o *latencyRecorder) {
+	if o.requests == 0 {
+		return
+	}
+	if l.requests == 0 || o.minMs < l.minMs {
+		l.minMs = o.minMs
+	}
+	l.maxMs = math.Max(l.maxMs, o.maxMs)
+	l.requests += o.requests
+	l.errors += o.errors
+	l.sumMs += o.sumMs
+	for i, n := range o.buckets {
+		l.buckets[i] += n
+	}
+}
+
+// recorderShards is the number of independently locked parts of a Recorder. Add only locks the shard
+// of the worker of the record, and Summary, Histogram and Take merge all shards.
+const recorderShards = 16
+
+// recorderTotals contains the aggregated requests of a shard or of the whole Recorder
+type recorderTotals struct {
+	start      time.Time
+	end        time.Time
+	setRateSum float64
+	overall    *latencyRecorder // nil until the first request
+	endpoints  map[string]*latencyRecorder
+}
+
+func newRecorderTotals() *recorderTotals {
+	return &recorderTotals{overall: newLatencyRecorder(), endpoints: make(map[string]*latencyRecorder)}
+}
+
+func (t *recorderTotals) addRequest(name string, start time.Time, end time.Time, setRate float64, elapsedMs float64, isError bool) {
+	if t.overall == nil {
+		t.overall = newLatencyRecorder()
+		t.endpoints = make(map[string]*latencyRecorder)
+	}
+	if t.start.IsZero() || start.Before(t.start) {
+		t.start = start
+	}
+	if end.After(t.end) {
+		t.end = end
+	}
+	t.setRateSum += setRate
+	t.overall.add(elapsedMs, isError)
+	endpoint, ok := t.endpoints[name]
+	if !ok {
+		endpoint = newLatencyRecorder()
+		t.endpoints[name] = endpoint
+	}
+	endpoint.add(elapsedMs, isError)
+}
+
+// merge adds the requests of o
+func (t *recorderTotals) merge(o *recorderTotals) {
+	if o.overall == nil {
+		return
+	}
+	if !o.start.IsZero() && (t.start.IsZero() || o.start.Before(t.start)) {
+		t.start = o.start
+	}
+	if o.end.After(t.end) {
+		t.end = o.end
+	}
+	t.setRateSum += o.setRateSum
+	t.overall.merge(o.overall)
+	for name, e := range o.endpoints {
+		endpoint, ok := t.endpoints[name]
+		if !ok {
+			endpoint = newLatencyRecorder()
+			t.endpoints[name] = endpoint
+		}
+		endpoint.merge(e)
+	}
+}
+
+type recorderShard struct {
+	mu sync.Mutex
+	recorderTotals
+}
+
+// Recorder aggregates finished requests per endpoint in constant memory. It is safe for concurrent use.
+type Recorder struct {
+	shards [recorderShards]recorderShard
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Reset discards all recorded requests
+func (r *Recorder) Reset() {
+	for i := range r.shards {
+		shard := &r.shards[i]
+		shard.mu.Lock()
+		shard.recorderTotals = recorderTotals{}
+		shard.mu.Unlock()
+	}
+}
+
+// Add records a finished request
+func (r *Recorder) Add(record *logformat.Record) {
+	name := record.Name
+	if name == "" {
+		// log files written before requests had names
+		name = record.Method + " " + record.URL
+	}
+	end := record.Timestamp.Add(time.Duration(record.ElapsedMs * float64(time.Millisecond)))
+	isError := IsError(record)
+
+	shard := &r.shards[uint(record.Worker)%recorderShards]
+	shard.mu.Lock()
+	shard.addRequest(name, record.Timestamp, end, record.SetRate, record.ElapsedMs, isError)
+	shard.mu.Unlock()
+}
+
+// totals merges the shards. With reset the shards are emptied at the same time.
+func (r *Recorder) totals(reset bool) *recorderTotals {
+	t := newRecorderTotals()
+	for i := range r.shards {
+		shard := &r.shards[i]
+		shard.mu.Lock()
+		t.merge(&shard.recorderTotals)
+		if reset {
+			shard.recorderTotals = recorderTotals{}
+		}
+		shard.mu.Unlock()
+	}
+	return t
+}
+
+// Summary returns the summary of all recorded requests with the endpoints sorted by name
+func (r *Recorder) Summary() *RunSummary {
+	return r.totals(false).summary()
+}
+
+// Take returns the summary of all recorded requests and discards them
+func (r *Recorder) Take() *RunSummary {
+	return r.totals(true).summary()
+}
+
+func (t *recorderTotals) summary() *RunSummary {
+	s := &RunSummary{
+		Version:   SummaryVersion,
+		Start:     t.start,
+		Endpoints: make([]EndpointSummary, 0, len(t.endpoints)),
+	}
+	if !t.start.IsZero() {
+		s.DurationS = t.end.Sub(t.start).Seconds()
+	}
+	s.Overall = t.overall.summary("", s.DurationS)
+	for name, endpoint := range t.endpoints {
+		s.Endpoints = append(s.Endpoints, endpoint.summary(name, s.DurationS))
+	}
+	sort.Slice(s.Endpoints, func(i, j int) bool { return s.Endpoints[i].Name < s.Endpoints[j].Name })
+	return s
+}
+
+// Histogram returns the latency histogram of all recorded requests from the smallest to the largest latency
+// with the given number of buckets per decade, which must divide 100
+func (r *Recorder) Histogram(bucketsPerDecade int) []HistogramBucket {
+	o := r.totals(false).overall
+	histogram := []HistogramBucket{}
+	if o.requests == 0 {
+		return histogram
+	}
+	step := recorderBucketsPerDecade / bucketsPerDecade
+	last := len(o.buckets) - 1
+	// the coarse bucket k contains the fine buckets k*step-step+1 to k*step
+	for k := (bucketIndex(o.minMs) + step - 1) / step; ; k++ {
+		lo, hi := max(k*step-step+1, 0), min(k*step, last)
+		bucket := HistogramBucket{UpperMs: bucketUpper(k * step)}
+		for _, n := range o.buckets[lo : hi+1] {
+			bucket.Count += n
+		}
+		histogram = append(histogram, bucket)
+		if hi == last || bucket.UpperMs >= o.maxMs {
+			break
+		}
+	}
+	return histogram
+}
+
+// Summarize reads all records of the log file into a summary
+func Summarize(decoder *logformat.Decoder) (*RunSummary, error) {
+	recorder := NewRecorder()
+	var record logformat.Record
+	for {
+		err := decoder.Decode(&record)
+		if err == io.EOF {
+			return recorder.Summary(), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !record.IsAnnotation() {
+			recorder.Add(&record)
+		}
+	}
+}
+
+// WriteSummary writes the summary as indented JSON
+func WriteSummary(w io.Writer, summary *RunSummary) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(summary)
+}
+
+// WriteSummaryText writes the summary as human readable text with a table of the endpoints
+func WriteSummaryText(w io.Writer, summary *RunSummary) error {
+	s := summary.Overall
+	_, _ = fmt.Fprintf(w, "Requests:   %d in %.1fs (%.1f RPS)\n", s.Requests, summary.DurationS, s.Throughput)
+	_, _ = fmt.Fprintf(w, "Errors:     %d (%.2f%%)\n", s.Errors, s.ErrorRate*100)
+	_, _ = fmt.Fprintf(w, "Latency:    %s\n", formatLatency(s.Latency))
+	if len(summary.Endpoints) == 0 {
+		return nil
+	}
+
+	_, _ = fmt.Fprintf(w, "\nEndpoints:\n")
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "name\trequests\terrors\trate\tp50 ms\tp99 ms\tmax ms\t\n")
+	for _, e := range summary.Endpoints {
+		_, _ = fmt.Fprintf(tw, "%s\t%d\t%.2f%%\t%.1f\t%.1f\t%.1f\t%.1f\t\n",
+			e.Name, e.Requests, e.ErrorRate*100, e.Throughput, e.Latency.P50Ms, e.Latency.P99Ms, e.Latency.MaxMs)
+	}
+	return tw.Flush()
+}
+
+// ReadSummary reads a summary written with WriteSummary
+func ReadSummary(r io.Reader) (*RunSummary, error) {
+	summary := &RunSummary{}
+	if err := json.NewDecoder(r).Decode(summary); err != nil {
+		return nil, err
+	}
+	if summary.Version != SummaryVersion {
+		return nil, fmt.Errorf("unsupported summary version %d, expected %d", summary.Version, SummaryVersion)
+	}
+	return summary, nil
+}
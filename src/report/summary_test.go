@@ -0,0 +1,169 @@
+package report
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/s-macke/slapperx/src/logformat"
+)
+
+func summarizeTestdata(t *testing.T) *RunSummary {
+	file, err := os.Open("testdata/run.csv")
+	if err != nil {
+		t.Fatalf("Failed to open testdata/run.csv: %v", err)
+	}
+	defer file.Close()
+	summary, err := Summarize(logformat.NewDecoder(file))
+	if err != nil {
+		t.Fatalf("Failed to summarize: %v", err)
+	}
+	return summary
+}
+
+func TestSummarize(t *testing.T) {
+	s := summarizeTestdata(t)
+	if s.Version != SummaryVersion || s.DurationS != 2.9 {
+		t.Errorf("Unexpected version %d or duration %f", s.Version, s.DurationS)
+	}
+	if s.Overall.Requests != 6 || s.Overall.Errors != 3 || s.Overall.ErrorRate != 0.5 {
+		t.Errorf("Unexpected overall summary %+v", s.Overall)
+	}
+	if len(s.Endpoints) != 2 || s.Endpoints[0].Name != "item" || s.Endpoints[1].Name != "users" {
+		t.Fatalf("Expected endpoints item and users, got %+v", s.Endpoints)
+	}
+	if s.Endpoints[0].Requests != 2 || s.Endpoints[0].Errors != 2 || s.Endpoints[1].Requests != 4 || s.Endpoints[1].Errors != 1 {
+		t.Errorf("Unexpected endpoint counts %+v", s.Endpoints)
+	}
+	l := s.Overall.Latency
+	if l.MinMs != 10 || l.MaxMs != 1000 || l.P99Ms != 1000 {
+		t.Errorf("Unexpected latency %+v", l)
+	}
+}
+
+func TestWriteSummaryText(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteSummaryText(&buf, summarizeTestdata(t)); err != nil {
+		t.Fatalf("Failed to write summary: %v", err)
+	}
+	text := buf.String()
+	for _, expected := range []string{"Requests:   6 in 2.9s", "Errors:     3 (50.00%)", "Endpoints:", "item ", "users "} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("Expected %q in summary:\n%s", expected, text)
+		}
+	}
+}
+
+func TestRecorderPercentileAccuracy(t *testing.T) {
+	recorder := NewRecorder()
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	var latencies []float64
+	for i := 1; i <= 1000; i++ {
+		ms := float64(i) * 1.7
+		latencies = append(latencies, ms)
+		recorder.Add(&logformat.Record{Timestamp: start, ElapsedMs: ms, Status: 200, Name: "a"})
+	}
+	s := recorder.Summary()
+	exact := SummarizeLatencies(latencies)
+	for _, p := range [][2]float64{{s.Overall.Latency.P50Ms, exact.P50Ms}, {s.Overall.Latency.P90Ms, exact.P90Ms}, {s.Overall.Latency.P99Ms, exact.P99Ms}} {
+		if math.Abs(p[0]-p[1])/p[1] > 0.025 {
+			t.Errorf("Percentile %f differs more than 2.5%% from %f", p[0], p[1])
+		}
+	}
+
+	recorder.Reset()
+	if s := recorder.Summary(); s.Overall.Requests != 0 || len(s.Endpoints) != 0 {
+		t.Errorf("Expected empty summary after reset, got %+v", s)
+	}
+}
+
+func TestRecorderWorkers(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	single := NewRecorder()
+	sharded := NewRecorder()
+	var wg sync.WaitGroup
+	for worker := range 40 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 50 {
+				record := &logformat.Record{
+					Timestamp: start.Add(time.Duration(worker*50+i) * time.Millisecond),
+					ElapsedMs: float64(worker + i + 1),
+					Status:    200 + 300*(i%5/4),
+					SetRate:   10,
+					Name:      []string{"a", "b"}[worker%2],
+					Worker:    worker,
+				}
+				sharded.Add(record)
+				unsharded := *record
+				unsharded.Worker = 0
+				single.Add(&unsharded)
+			}
+		}()
+	}
+	wg.Wait()
+
+	expected, s := single.Summary(), sharded.Summary()
+	if s.Overall != expected.Overall || s.DurationS != expected.DurationS || !s.Start.Equal(expected.Start) {
+		t.Errorf("Expected %+v, got %+v", expected.Overall, s.Overall)
+	}
+	if s.Overall.Requests != 2000 || s.Overall.Errors != 400 || len(s.Endpoints) != 2 {
+		t.Errorf("Unexpected summary %+v", s.Overall)
+	}
+	for i := range s.Endpoints {
+		if s.Endpoints[i] != expected.Endpoints[i] {
+			t.Errorf("Expected %+v, got %+v", expected.Endpoints[i], s.Endpoints[i])
+		}
+	}
+}
+
+func TestSummaryRoundTrip(t *testing.T) {
+	s := summarizeTestdata(t)
+	var buf bytes.Buffer
+	if err := WriteSummary(&buf, s); err != nil {
+		t.Fatalf("Failed to write summary: %v", err)
+	}
+	decoded, err := ReadSummary(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("Failed to read summary: %v", err)
+	}
+	if decoded.Overall != s.Overall || len(decoded.Endpoints) != len(s.Endpoints) {
+		t.Errorf("JSON round trip changed the summary")
+	}
+
+	if _, err := ReadSummary(strings.NewReader(`{"version": 99}`)); err == nil {
+		t.Errorf("Expected error for unsupported version")
+	}
+}
+
+func TestRecorderHistogram(t *testing.T) {
+	recorder := NewRecorder()
+	if len(recorder.Histogram(10)) != 0 {
+		t.Errorf("Expected empty histogram")
+	}
+	for _, ms := range []float64{1, 1.1, 5, 10, 99} {
+		recorder.Add(&logformat.Record{ElapsedMs: ms, Status: 200})
+	}
+	histogram := recorder.Histogram(1)
+	if len(histogram) != 3 {
+		t.Fatalf("Expected 3 buckets, got %+v", histogram)
+	}
+	expected := []HistogramBucket{{UpperMs: 1, Count: 1}, {UpperMs: 10, Count: 3}, {UpperMs: 100, Count: 1}}
+	for i, b := range histogram {
+		if math.Abs(b.UpperMs-expected[i].UpperMs) > 1e-9 || b.Count != expected[i].Count {
+			t.Errorf("Bucket %d: expected %+v, got %+v", i, expected[i], b)
+		}
+	}
+	var total int64
+	for _, b := range recorder.Histogram(10) {
+		total += b.Count
+	}
+	if total != 5 {
+		t.Errorf("Expected 5 requests in the fine histogram, got %d", total)
+	}
+}
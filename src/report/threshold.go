@@ -0,0 +1,172 @@
+package report
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Threshold is a pass/fail criterion of a run like p99<300ms, errors<0.1% or rate>=0.95*set
+type Threshold struct {
+	Expr       string
+	metric     string  // p, min, mean, max, errors or rate
+	percentile float64 // for metric p
+	op         string
+	limit      float64 // milliseconds, error fraction or requests per second
+	perSetRate bool    // the limit is a factor of the set rate
+}
+
+// ThresholdResult is the evaluation of a threshold
+type ThresholdResult struct {
+	Threshold string  `json:"threshold"`
+	Value     float64 `json:"value"`
+	Limit     float64 `json:"limit"`
+	Passed    bool    `json:"passed"`
+}
+
+// ParseThreshold parses an expression of the form metric operator limit.
+// The metrics are p<percentile>, min, mean and max with a duration as limit,
+// errors with a fraction or percentage as limit and rate with requests per second
+// or a factor of the set rate like 0.95*set as limit.
+func ParseThreshold(expr string) (*Threshold, error) {
+	t := &Threshold{Expr: expr}
+	s := strings.ReplaceAll(expr, " ", "")
+	idx := strings.IndexAny(s, "<>")
+	if idx <= 0 {
+		return nil, fmt.Errorf("threshold %q: expected metric, operator and limit like p99<300ms", expr)
+	}
+	metric := s[:idx]
+	t.op = s[idx : idx+1]
+	if strings.HasPrefix(s[idx+1:], "=") {
+		t.op += "="
+	}
+	limit := s[idx+len(t.op):]
+
+	var err error
+	switch {
+	case metric == "min" || metric == "mean" || metric == "max":
+		t.metric = metric
+		t.limit, err = parseLatencyLimit(limit)
+	case strings.HasPrefix(metric, "p"):
+		t.metric = "p"
+		t.percentile, err = strconv.ParseFloat(metric[1:], 64)
+		if err != nil || t.percentile <= 0 || t.percentile > 100 {
+			return nil, fmt.Errorf("threshold %q: invalid percentile %q", expr, metric)
+		}
+		t.limit, err = parseLatencyLimit(limit)
+	case metric == "errors":
+		t.metric = metric
+		t.limit, err = parseFraction(limit)
+	case metric == "rate":
+		t.metric = metric
+		if factor, ok := strings.CutSuffix(limit, "*set"); ok {
+			t.perSetRate = true
+			limit = factor
+		}
+		t.limit, err = strconv.ParseFloat(limit, 64)
+	default:
+		return nil, fmt.Errorf("threshold %q: unknown metric %q, expected p<percentile>, min, mean, max, errors or rate", expr, metric)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("threshold %q: invalid limit %q: %w", expr, limit, err)
+	}
+	return t, nil
+}
+
+// parseLatencyLimit parses a duration like 300ms or a plain number of milliseconds
+func parseLatencyLimit(s string) (float64, error) {
+	if ms, err := strconv.ParseFloat(s, 64); err == nil {
+		return ms, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	return float64(d) / float64(time.Millisecond), nil
+}
+
+// parseFraction parses a fraction like 0.001 or a percentage like 0.1%
+func parseFraction(s string) (float64, error) {
+	percent, ok := strings.CutSuffix(s, "%")
+	v, err := strconv.ParseFloat(percent, 64)
+	if ok {
+		v /= 100
+	}
+	return v, err
+}
+
+// Evaluate checks the threshold against all recorded requests
+func (t *Threshold) Evaluate(r *Recorder) ThresholdResult {
+	result := ThresholdResult{Threshold: t.Expr, Limit: t.limit}
+	totals := r.totals(false)
+	o := totals.overall
+	switch t.metric {
+	case "p":
+		if o.requests > 0 {
+			result.Value = o.percentile(t.percentile)
+		}
+	case "min":
+		result.Value = o.minMs
+	case "mean":
+		if o.requests > 0 {
+			result.Value = o.sumMs / float64(o.requests)
+		}
+	case "max":
+		result.Value = o.maxMs
+	case "errors":
+		if o.requests > 0 {
+			result.Value = float64(o.errors) / float64(o.requests)
+		}
+	case "rate":
+		if durationS := totals.end.Sub(totals.start).Seconds(); durationS > 0 {
+			result.Value = float64(o.requests) / durationS
+		}
+		if t.perSetRate && o.requests > 0 {
+			result.Limit = t.limit * totals.setRateSum / float64(o.requests)
+		}
+	}
+
+	// latency and errors of a run without requests are unknown, e.g. if the target never answered
+	if o.requests == 0 && t.metric != "rate" {
+		return result
+	}
+	switch t.op {
+	case "<":
+		result.Passed = result.Value < result.Limit
+	case "<=":
+		result.Passed = result.Value <= result.Limit
+	case ">":
+		result.Passed = result.Value > result.Limit
+	case ">=":
+		result.Passed = result.Value >= result.Limit
+	}
+	return result
+}
+
+// Irrecoverable returns true if an upper limit on latency or errors can no longer be met,
+// because more requests than allowed already failed it. expectedRequests is the number of
+// requests at the end of the run, 0 if the run is not bounded.
+func (t *Threshold) Irrecoverable(r *Recorder, expectedRequests int64) bool {
+	if t.op != "<" && t.op != "<=" {
+		return false
+	}
+	o := r.totals(false).overall
+	if o.requests == 0 {
+		return false
+	}
+	if t.metric == "max" {
+		return o.maxMs > t.limit || (t.op == "<" && o.maxMs == t.limit)
+	}
+	if expectedRequests <= 0 {
+		return false
+	}
+	expected := float64(max(expectedRequests, o.requests))
+	switch t.metric {
+	case "p":
+		return float64(o.countAbove(t.limit)) > (1-t.percentile/100)*expected
+	case "errors":
+		return float64(o.errors) > t.limit*expected
+	}
+	return false
+}
@@ -0,0 +1,150 @@
+package report
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/s-macke/slapperx/src/logformat"
+)
+
+// newTestRecorder records 100 requests over one second at a set rate of 100 RPS,
+// with latencies of 1 to 100ms and the given number of errors
+func newTestRecorder(errors int) *Recorder {
+	recorder := NewRecorder()
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i := 1; i <= 100; i++ {
+		status := 200
+		if i <= errors {
+			status = 500
+		}
+		recorder.Add(&logformat.Record{
+			Timestamp: start.Add(time.Duration(i-1) * 10 * time.Millisecond),
+			ElapsedMs: float64(i),
+			Status:    status,
+			SetRate:   100,
+		})
+	}
+	return recorder
+}
+
+func TestParseThresholdErrors(t *testing.T) {
+	for _, expr := range []string{"p99", "<300ms", "foo<1", "p0<1ms", "p101<1ms", "p99<abc", "errors<x%", "rate>=0.9*foo"} {
+		if _, err := ParseThreshold(expr); err == nil {
+			t.Errorf("Expected error for %q", expr)
+		}
+	}
+}
+
+func TestEvaluatePercentileNearLimit(t *testing.T) {
+	recorder := NewRecorder()
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i := 1; i <= 1000; i++ {
+		recorder.Add(&logformat.Record{Timestamp: start, ElapsedMs: float64(i), Status: 200})
+	}
+	// the exact p99 is 990ms, the bucket containing it ends at 1000ms
+	threshold, _ := ParseThreshold("p99<995ms")
+	if result := threshold.Evaluate(recorder); !result.Passed || math.Abs(result.Value-990) > 5 {
+		t.Errorf("Expected p99 close to 990ms, got %+v", result)
+	}
+}
+
+func TestEvaluateThreshold(t *testing.T) {
+	recorder := newTestRecorder(2)
+	tests := []struct {
+		expr   string
+		passed bool
+	}{
+		{"p99<300ms", true},
+		{"p99 < 0.09s", false},
+		{"p50<=51", true},
+		{"p99.9<100ms", false},
+		{"mean<60ms", true},
+		{"max<100ms", false},
+		{"max<=100ms", true},
+		{"min>2ms", false},
+		{"errors<3%", true},
+		{"errors<0.01", false},
+		{"rate>=0.9*set", true},
+		{"rate>=0.95*set", false}, // the last request ends after 1.09s
+		{"rate>200", false},
+	}
+	for _, tt := range tests {
+		threshold, err := ParseThreshold(tt.expr)
+		if err != nil {
+			t.Fatalf("Failed to parse %q: %v", tt.expr, err)
+		}
+		if result := threshold.Evaluate(recorder); result.Passed != tt.passed {
+			t.Errorf("%s: expected passed %v, got %+v", tt.expr, tt.passed, result)
+		}
+	}
+}
+
+func TestEvaluateWithoutRequests(t *testing.T) {
+	recorder := NewRecorder()
+	tests := []struct {
+		expr   string
+		passed bool
+	}{
+		{"p99<300ms", false},
+		{"min>=0ms", false},
+		{"mean<1s", false},
+		{"max<1s", false},
+		{"errors<1%", false},
+		{"errors<=1", false},
+		{"rate>=0", true},
+		{"rate>1", false},
+	}
+	for _, tt := range tests {
+		threshold, err := ParseThreshold(tt.expr)
+		if err != nil {
+			t.Fatalf("Failed to parse %q: %v", tt.expr, err)
+		}
+		if result := threshold.Evaluate(recorder); result.Passed != tt.passed {
+			t.Errorf("%s: expected passed %v, got %+v", tt.expr, tt.passed, result)
+		}
+	}
+}
+
+func TestThresholdIrrecoverable(t *testing.T) {
+	recorder := newTestRecorder(2)
+	tests := []struct {
+		expr     string
+		expected int64
+		result   bool
+	}{
+		{"max<50ms", 0, true},
+		{"max<200ms", 0, false},
+		{"errors<1%", 0, false},    // unbounded run
+		{"errors<1%", 1000, false}, // 2 of 1000 allowed errors
+		{"errors<1%", 100, true},
+		{"p99<50ms", 10000, false}, // 50 of 100 allowed slow requests
+		{"p99<50ms", 1000, true},
+		{"p99>50ms", 1000, false}, // lower limits can always recover
+		{"rate>=0.95*set", 1000, false},
+	}
+	for _, tt := range tests {
+		threshold, _ := ParseThreshold(tt.expr)
+		if result := threshold.Irrecoverable(recorder, tt.expected); result != tt.result {
+			t.Errorf("%s with %d expected requests: expected %v, got %v", tt.expr, tt.expected, tt.result, result)
+		}
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	results := []ThresholdResult{
+		{Threshold: "p99<300ms", Value: 120, Limit: 300, Passed: true},
+		{Threshold: "errors<0.1%", Value: 0.02, Limit: 0.001, Passed: false},
+	}
+	var buf bytes.Buffer
+	if err := WriteJUnit(&buf, results, time.Now(), 10*time.Second); err != nil {
+		t.Fatalf("Failed to write JUnit: %v", err)
+	}
+	for _, expected := range []string{`tests="2" failures="1"`, `name="p99&lt;300ms"`, `<failure message="threshold errors&lt;0.1% failed">`} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("Expected %q in JUnit XML:\n%s", expected, buf.String())
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package slapperx
+
+import (
+	"flag"
+	"fmt"
+	"github.com/s-macke/slapperx/src/logformat"
+	"github.com/s-macke/slapperx/src/report"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ReportMain analyzes a log file written with -log and returns the exit code
+func ReportMain(args []string) int {
+	flags := flag.NewFlagSet("report", flag.ContinueOnError)
+	interval := flags.Duration("interval", time.Second, "Length of the time slices")
+	jsonFile := flags.String("json", "", "Also write the report as JSON to this file (- for stdout instead of text)")
+	htmlFile := flags.String("html", "", "Also write the report as HTML with charts to this file")
+	summaryFile := flags.String("summary", "", "Also write the summary for the compare command to this file")
+	flags.Usage = func() {
+		_, _ = fmt.Fprintf(flags.Output(), "Usage: slapperx report [options] logfile\n\nOptions:\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 || *interval <= 0 {
+		flags.Usage()
+		return 2
+	}
+
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Failed to open log file: %v\n", err)
+		return 1
+	}
+	defer file.Close()
+
+	r, err := report.Analyze(logformat.NewDecoder(file), *interval)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Failed to read log file: %v\n", err)
+		return 1
+	}
+
+	if *jsonFile == "-" {
+		err = report.WriteJSON(os.Stdout, r)
+	} else {
+		err = report.WriteText(os.Stdout, r)
+		if err == nil && *jsonFile != "" {
+			err = writeJSONReport(*jsonFile, r)
+		}
+	}
+	if err == nil && *htmlFile != "" {
+		err = writeHTMLReport(*htmlFile, r, flags.Arg(0), nil)
+	}
+	if err == nil && *summaryFile != "" {
+		err = writeLogSummary(*summaryFile, file)
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Failed to write report: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func writeJSONReport(path string, r *report.Report) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = report.WriteJSON(file, r)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// writeLogSummary reads the log file again from the start and writes its summary
+func writeLogSummary(path string, file *os.File) error {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	summary, err := report.Summarize(logformat.NewDecoder(file))
+	if err != nil {
+		return err
+	}
+	return writeSummary(path, summary)
+}
+
+func writeHTMLReport(path string, r *report.Report, logPath string, config []report.ConfigEntry) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = report.WriteHTML(file, r, "SlapperX report "+filepath.Base(logPath), config)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// tempLogFile returns the path of a new temporary log file
+func tempLogFile() (string, error) {
+	file, err := os.CreateTemp("", "slapperx-*.log")
+	if err != nil {
+		return "", err
+	}
+	return file.Name(), file.Close()
+}
+
+// writeRunHTMLReport analyzes the log file of the finished run and writes it as HTML report
+func writeRunHTMLReport(path string, logPath string) {
+	file, err := os.Open(logPath)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Failed to open log file: %v\n", err)
+		return
+	}
+	defer file.Close()
+
+	r, err := report.Analyze(logformat.NewDecoder(file), time.Second)
+	if err == nil {
+		err = writeHTMLReport(path, r, logPath, runConfig())
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Failed to write HTML report: %v\n", err)
+	}
+}
+
+// runConfig lists the command line flags of the run. Credentials are removed by the report.
+func runConfig() []report.ConfigEntry {
+	var config []report.ConfigEntry
+	flag.VisitAll(func(f *flag.Flag) {
+		config = append(config, report.ConfigEntry{Key: "-" + f.Name, Value: f.Value.String()})
+	})
+	return config
+}
+
+// writeRunSummary writes the summary of the finished run
+func writeRunSummary(path string) {
+	if err := writeSummary(path, stats.summary.Summary()); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Failed to write summary: %v\n", err)
+	}
+}
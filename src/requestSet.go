@@ -0,0 +1,149 @@
+package slapperx
+
+import (
+	"fmt"
+	"github.com/s-macke/slapperx/src/httpfile"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// RequestState is a request of the HTTP file with its number, starting at 1, and whether it is sent
+type RequestState struct {
+	Number  int
+	Name    string
+	Tags    []string
+	Enabled bool
+}
+
+// RequestSet contains the requests of the HTTP file, which can be enabled and disabled while the test runs.
+// The workers read the enabled requests without locking, changes replace the list of enabled requests.
+type RequestSet struct {
+	requests []httpfile.Request
+
+	mu      sync.Mutex // serializes changes
+	enabled []bool
+	active  atomic.Pointer[[]int] // indices of the enabled requests
+}
+
+func NewRequestSet(requests []httpfile.Request) *RequestSet {
+	s := &RequestSet{
+		requests: requests,
+		enabled:  make([]bool, len(requests)),
+	}
+	for i := range s.enabled {
+		s.enabled[i] = true
+	}
+	s.update()
+	return s
+}
+
+// update publishes the enabled requests to the workers, must be called with mu held
+func (s *RequestSet) update() {
+	active := make([]int, 0, len(s.requests))
+	for i, enabled := range s.enabled {
+		if enabled {
+			active = append(active, i)
+		}
+	}
+	s.active.Store(&active)
+}
+
+// next returns a copy of the n-th enabled request in round robin order, nil if all requests are disabled
+func (s *RequestSet) next(n int64) *httpfile.Request {
+	active := *s.active.Load()
+	if len(active) == 0 {
+		return nil
+	}
+	request := s.requests[active[int(n%int64(len(active)))]]
+	request.Body, _ = request.GetBody()
+	return &request
+}
+
+// match returns the indices of the requests selected by a number, a name, @tag or all
+func (s *RequestSet) match(target string) ([]int, error) {
+	var indices []int
+	for i, r := range s.requests {
+		switch {
+		case target == "all",
+			strings.HasPrefix(target, "@") && slices.Contains(r.Tags, target[1:]),
+			r.Name == target:
+			indices = append(indices, i)
+		}
+	}
+	if number, err := strconv.Atoi(target); err == nil && len(indices) == 0 {
+		if number < 1 || number > len(s.requests) {
+			return nil, fmt.Errorf("no request number %d", number)
+		}
+		indices = []int{number - 1}
+	}
+	if len(indices) == 0 {
+		return nil, fmt.Errorf("no request or tag %q", target)
+	}
+	return indices, nil
+}
+
+// SetEnabled enables or disables the requests selected by target and returns their number
+func (s *RequestSet) SetEnabled(target string, enabled bool) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	indices, err := s.match(target)
+	if err != nil {
+		return 0, err
+	}
+	for _, i := range indices {
+		s.enabled[i] = enabled
+	}
+	s.update()
+	return len(indices), nil
+}
+
+// Toggle disables the requests selected by target if any of them is enabled and enables them otherwise.
+// It returns the new state and the number of requests.
+func (s *RequestSet) Toggle(target string) (bool, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	indices, err := s.match(target)
+	if err != nil {
+		return false, 0, err
+	}
+	enabled := true
+	for _, i := range indices {
+		if s.enabled[i] {
+			enabled = false
+		}
+	}
+	for _, i := range indices {
+		s.enabled[i] = enabled
+	}
+	s.update()
+	return enabled, len(indices), nil
+}
+
+// List returns the state of all requests in the order of the HTTP file
+func (s *RequestSet) List() []RequestState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	states := make([]RequestState, len(s.requests))
+	for i, r := range s.requests {
+		states[i] = RequestState{Number: i + 1, Name: r.Name, Tags: r.Tags, Enabled: s.enabled[i]}
+	}
+	return states
+}
+
+// Tags returns the sorted tags of all requests
+func (s *RequestSet) Tags() []string {
+	var tags []string
+	for _, r := range s.requests {
+		for _, tag := range r.Tags {
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
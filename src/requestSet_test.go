@@ -0,0 +1,166 @@
+package slapperx
+
+import (
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/s-macke/slapperx/src/httpfile"
+)
+
+// newTestRequestSet creates the requests 1 "login" @auth, 2 "2" @read, 3 "items" @read and 4 "GET http://test.local/4"
+func newTestRequestSet(t *testing.T) *RequestSet {
+	files := []httpfile.HTTPFile{
+		{Name: "login", Method: "POST", URL: "http://test.local/login", Body: "user", Tags: []string{"auth"}},
+		{Name: "2", Method: "GET", URL: "http://test.local/2", Tags: []string{"read"}},
+		{Name: "items", Method: "GET", URL: "http://test.local/items", Tags: []string{"read"}},
+		{Method: "GET", URL: "http://test.local/4"},
+	}
+	var requests []httpfile.Request
+	for _, file := range files {
+		request, err := httpfile.NewRequest(file, false)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		requests = append(requests, request)
+	}
+	return NewRequestSet(requests)
+}
+
+func enabledNumbers(s *RequestSet) []int {
+	var numbers []int
+	for _, state := range s.List() {
+		if state.Enabled {
+			numbers = append(numbers, state.Number)
+		}
+	}
+	return numbers
+}
+
+func TestRequestSetMatch(t *testing.T) {
+	tests := []struct {
+		target   string
+		expected []int // indices
+		fails    bool
+	}{
+		{"login", []int{0}, false},
+		{"2", []int{1}, false}, // the name wins over the number
+		{"3", []int{2}, false},
+		{"4", []int{3}, false},
+		{"GET http://test.local/4", []int{3}, false},
+		{"@read", []int{1, 2}, false},
+		{"@auth", []int{0}, false},
+		{"all", []int{0, 1, 2, 3}, false},
+		{"0", nil, true},
+		{"5", nil, true},
+		{"-1", nil, true},
+		{"@write", nil, true},
+		{"logout", nil, true},
+	}
+	s := newTestRequestSet(t)
+	for _, test := range tests {
+		indices, err := s.match(test.target)
+		if (err != nil) != test.fails {
+			t.Errorf("%q: unexpected error %v", test.target, err)
+			continue
+		}
+		if !slices.Equal(indices, test.expected) {
+			t.Errorf("%q: expected %v, got %v", test.target, test.expected, indices)
+		}
+	}
+}
+
+func TestRequestSetToggle(t *testing.T) {
+	tests := []struct {
+		disabled []string // disabled before the toggle
+		target   string
+		enabled  bool
+		count    int
+		expected []int // enabled numbers afterwards
+	}{
+		{nil, "login", false, 1, []int{2, 3, 4}},
+		{[]string{"login"}, "login", true, 1, []int{1, 2, 3, 4}},
+		// one enabled request of the tag disables all of them
+		{[]string{"2"}, "@read", false, 2, []int{1, 4}},
+		{[]string{"2", "items"}, "@read", true, 2, []int{1, 2, 3, 4}},
+		{[]string{"@read"}, "all", false, 4, nil},
+		{[]string{"all"}, "all", true, 4, []int{1, 2, 3, 4}},
+	}
+	for _, test := range tests {
+		s := newTestRequestSet(t)
+		for _, target := range test.disabled {
+			if _, err := s.SetEnabled(target, false); err != nil {
+				t.Fatalf("Failed to disable %q: %v", target, err)
+			}
+		}
+		enabled, count, err := s.Toggle(test.target)
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", test.target, err)
+			continue
+		}
+		if enabled != test.enabled || count != test.count {
+			t.Errorf("%q after disabling %v: expected %v for %d requests, got %v for %d",
+				test.target, test.disabled, test.enabled, test.count, enabled, count)
+		}
+		if numbers := enabledNumbers(s); !slices.Equal(numbers, test.expected) {
+			t.Errorf("%q after disabling %v: expected enabled %v, got %v", test.target, test.disabled, test.expected, numbers)
+		}
+	}
+	if _, _, err := newTestRequestSet(t).Toggle("9"); err == nil {
+		t.Errorf("Expected error for an unknown request")
+	}
+}
+
+func TestRequestSetNext(t *testing.T) {
+	s := newTestRequestSet(t)
+	if _, err := s.SetEnabled("@read", false); err != nil {
+		t.Fatalf("Failed to disable: %v", err)
+	}
+	var names []string
+	for n := int64(0); n < 4; n++ {
+		names = append(names, s.next(n).Name)
+	}
+	if expected := []string{"login", "GET http://test.local/4", "login", "GET http://test.local/4"}; !slices.Equal(names, expected) {
+		t.Errorf("Expected %v, got %v", expected, names)
+	}
+
+	if _, err := s.SetEnabled("all", false); err != nil {
+		t.Fatalf("Failed to disable: %v", err)
+	}
+	if request := s.next(0); request != nil {
+		t.Errorf("Expected no request with all requests disabled, got %q", request.Name)
+	}
+}
+
+// TestRequestSetConcurrent toggles requests while the workers take them. Run with -race.
+func TestRequestSetConcurrent(t *testing.T) {
+	s := newTestRequestSet(t)
+	var wg sync.WaitGroup
+	done := make(chan bool)
+	for worker := 0; worker < 4; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := int64(0); ; n++ {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if request := s.next(n); request != nil && request.Name == "" {
+					t.Errorf("Expected a named request")
+				}
+			}
+		}()
+	}
+	for i := 0; i < 1000; i++ {
+		for _, target := range []string{"@read", "login", "all", "4"} {
+			if _, _, err := s.Toggle(target); err != nil {
+				t.Errorf("Failed to toggle %q: %v", target, err)
+			}
+		}
+		_ = s.List()
+	}
+	close(done)
+	wg.Wait()
+}
@@ -2,8 +2,17 @@
 
 import (
 	"fmt"
+	"github.com/s-macke/slapperx/src/control"
 	"github.com/s-macke/slapperx/src/httpfile"
+	"github.com/s-macke/slapperx/src/logformat"
+	"github.com/s-macke/slapperx/src/metrics"
+	"github.com/s-macke/slapperx/src/report"
+	"github.com/s-macke/slapperx/src/tracing"
+	"github.com/s-macke/slapperx/src/web"
+	terminal "golang.org/x/term"
+	"net"
 	"os"
+	"sync"
 	"time"
 )
 
@@ -20,38 +29,216 @@
 )
 
 func Main() {
+	if len(os.Args) > 1 && os.Args[1] == "report" {
+		os.Exit(ReportMain(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "compare" {
+		os.Exit(CompareMain(os.Args[2:]))
+	}
+	os.Exit(run())
+}
+
+// run executes the load test and returns the exit code, 1 if a threshold failed and 2 on errors
+func run() (exitCode int) {
 	config := ParseFlags()
+	if err := checkRate(config.Rate); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Invalid -rate: %v\n", err)
+		return 2
+	}
 
-	requests, err := httpfile.HTTPFileParser(config.Targets, config.Overrides, true)
+	requests, err := httpfile.HTTPFileParser(config.Targets, config.Overrides, config.KeepAlive)
 	if err != nil {
 		_, _ = fmt.Fprintf(os.Stderr, "Failed to parse HTTP file: %v\n", err)
-		return
+		return 2
 	}
 	if len(requests) == 0 {
 		_, _ = fmt.Fprintf(os.Stderr, "No requests found in the HTTP file\n")
 	}
+	for i := range requests {
+		tracing.PrepareUnixSocketRequest(&requests[i].Request)
+	}
 	if config.Verbose {
 		fmt.Println("Requests:", len(requests))
 	}
 	quit := make(chan struct{}, 1)
 
+	sourceAddrs, err := tracing.ParseSourceAddrs(config.SourceIPs)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Failed to parse source addresses: %v\n", err)
+		return 2
+	}
+
+	clientConfig := tracing.Config{
+		Timeout: config.Timeout,
+		HTTP3:   config.HTTP3,
+
+		KeepAlive:           config.KeepAlive,
+		MaxConnsPerHost:     config.MaxConnsPerHost,
+		MaxIdleConnsPerHost: config.MaxIdle,
+		IdleConnTimeout:     config.IdleTimeout,
+		Connections:         config.Connections,
+
+		Proxy:       config.Proxy,
+		SourceAddrs: sourceAddrs,
+		Resolve:     config.Resolve,
+		DNSCacheTTL: config.DNSCacheTTL,
+		UnixSocket:  config.UnixSocket,
+	}
+	client, err := tracing.NewTracingClient(clientConfig)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Failed to create HTTP client: %v\n", err)
+		return 2
+	}
+
+	logFormat, err := logformat.ParseFormat(config.LogFormat)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 2
+	}
+	if config.HTMLReport != "" {
+		if config.LogFile == "" {
+			// the report is built from the log file, so keep a temporary one
+			config.LogFile, err = tempLogFile()
+			if err != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "Failed to create log file: %v\n", err)
+				return 2
+			}
+			defer os.Remove(config.LogFile)
+		}
+		// registered before the log file is closed, so it runs afterwards
+		defer writeRunHTMLReport(config.HTMLReport, config.LogFile)
+	}
+	if config.Summary != "" {
+		defer writeRunSummary(config.Summary)
+	}
 	var logFile *LogFile = nil
 	if config.LogFile != "" {
-		logFile = NewLogFile(config.LogFile)
+		logFile = NewLogFile(config.LogFile, logFormat)
 		defer logFile.Close()
 	}
 
-	stats = Stats{}
+	var metricsListener net.Listener
+	if config.MetricsAddr != "" {
+		metricsListener, err = net.Listen("tcp", config.MetricsAddr)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Failed to serve metrics: %v\n", err)
+			return 2
+		}
+		defer metricsListener.Close()
+	}
+	var webListener net.Listener
+	if config.Web != "" {
+		webListener, err = net.Listen("tcp", config.Web)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Failed to serve web UI: %v\n", err)
+			return 2
+		}
+		defer webListener.Close()
+	}
+	var controlListener net.Listener
+	if config.ControlAddr != "" {
+		controlListener, err = net.Listen("tcp", config.ControlAddr)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Failed to serve control API: %v\n", err)
+			return 2
+		}
+		defer controlListener.Close()
+	}
+	profiles, err := parseProfiles(config.Profiles)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 2
+	}
+	scale, err := parseScale(config.Scale)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 2
+	}
+	bucketEdges, err := parseBucketEdges(config.BucketEdges)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 2
+	}
+	if scale == scaleCustom && len(bucketEdges) == 0 {
+		_, _ = fmt.Fprintf(os.Stderr, "The custom scale requires -bucket-edges\n")
+		return 2
+	}
+
+	monitor, err := NewThresholdMonitor(config.Thresholds, config.Duration, config.ThresholdAbort)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 2
+	}
+	// registered before the UI is closed, so the results are printed afterwards
+	defer func() {
+		if !monitor.Finish(config.JUnit) {
+			exitCode = 1
+		}
+	}()
+
+	stats = Stats{
+		summary:     report.NewRecorder(),
+		metrics:     metrics.NewCollector(),
+		errors:      NewErrorLog(),
+		annotations: NewAnnotations(),
+	}
+
+	pusher, err := newPusher(config, stats.metrics)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Failed to push metrics: %v\n", err)
+		return 2
+	}
+	// the headless push loop, stopped on quit
+	var pushLoop sync.WaitGroup
+	if pusher != nil {
+		// deferred calls run in reverse order, so this runs after the workers have finished
+		// and the push loop has stopped, and the last push contains all requests
+		defer func() {
+			pushLoop.Wait()
+			pusher.Flush()
+		}()
+	}
+
+	// without a terminal the status is printed periodically
+	var headless *Headless
+	if config.Headless || !terminal.IsTerminal(int(os.Stdout.Fd())) {
+		headless = NewHeadless(config.StatusInterval)
+		// registered before the requests are finished, so it runs afterwards
+		defer headless.PrintSummary()
+	}
 
 	var resultChan chan ResultStruct = nil
-	if !config.Verbose {
-		ui = InitTerminal(config.MinY, config.MaxY)
+	if !config.Verbose && headless == nil {
+		ui = InitTerminal(config.MinY, config.MaxY, scale, bucketEdges)
 		defer ui.Close()
+		ui.thresholds = monitor
+		ui.pusher = pusher
 		stats.initializeTimingsBucket(ui.lbc.buckets)
 		resultChan = stats.timings.Listen()
 	}
 
-	trgt = NewTargeter(&requests, config.Timeout, logFile, config.Verbose, resultChan)
+	trgt = NewTargeter(&requests, client, logFile, config.Verbose, resultChan)
+	addGauges(stats.metrics)
+	if metricsListener != nil {
+		metrics.Serve(metricsListener, stats.metrics)
+	}
+	if pusher != nil && ui == nil {
+		// without UI there is no aggregation loop
+		pushLoop.Add(1)
+		go func() {
+			defer pushLoop.Done()
+			ticker := time.NewTicker(screenRefreshInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case now := <-ticker.C:
+					pusher.Tick(now)
+				case <-quit:
+					return
+				}
+			}
+		}()
+	}
 
 	defer func() {
 		close(quit)  // send all threads the quit signal
@@ -62,6 +249,7 @@ func Main() {
 
 	rampUpController := NewRamUpController(config.RampUp, config.Rate)
 	go rampUpController.startRampUpTimeProcess(ticker.GetRateChanger())
+	controller := NewController(rampUpController, ticker, trgt, profiles)
 
 	// start attackers
 	var onTickChan = ticker.Start()
@@ -70,11 +258,35 @@ func Main() {
 	trgt.Start(config.Workers, onTickChan)
 
 	// blocking
-	if !config.Verbose {
+	if ui != nil {
+		ui.controller = controller
 		ui.Show() // start Terminal output
 	}
 
-	// Create and start keyboard handler
-	keyboard := InitKeyboard(rampUpController)
-	keyboard.Start()
+	// Create and start keyboard handler, there is no keyboard without terminal
+	var keyboard *Keyboard
+	if headless != nil {
+		headless.Start()
+		controller.stop = headless.Stop
+	} else {
+		keyboard = InitKeyboard(controller)
+	}
+	if webListener != nil {
+		server := web.NewServer(controller)
+		server.Serve(webListener)
+		startDashboard(server, controller)
+	}
+	if controlListener != nil {
+		control.NewServer(controller).Serve(controlListener)
+	}
+	monitor.Start(controller.Stop)
+	if config.Duration > 0 {
+		time.AfterFunc(config.Duration, controller.Stop)
+	}
+	if headless != nil {
+		headless.Wait()
+	} else {
+		keyboard.Start()
+	}
+	return 0
 }
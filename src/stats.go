@@ -1,15 +1,24 @@
 package slapperx
 
+import (
+	"github.com/s-macke/slapperx/src/metrics"
+	"github.com/s-macke/slapperx/src/report"
+	"math"
+	"os"
+	"sync/atomic"
+)
+
 type StatsResponse struct {
 	status           [1024]counter
 	ErrorEof         counter
 	ErrorTimeout     counter
 	ErrorConnRefused counter
 	ErrorNoSuchHost  counter
+	ErrorProxy       counter
 }
 
 type Stats struct {
-	currentSetRate    float64
+	setRate           atomic.Uint64 // bits of the set rate, written by the ticker
 	requestsSent      counter
 	responsesReceived counter
 
@@ -17,13 +26,29 @@ type Stats struct {
 
 	// ring moving window buffer
 	timings *MovingWindow
+
+	// totals per request for the summary of the run
+	summary *report.Recorder
+
+	// counters per request for the metrics endpoint, not affected by reset
+	metrics *metrics.Collector
+
+	// recent error messages for the errors view
+	errors *ErrorLog
+
+	// notes taken during the run, not affected by reset
+	annotations *Annotations
 }
 
 func (s *Stats) reset() {
 	s.requestsSent.Store(0)
 	s.responsesReceived.Store(0)
 
-	s.timings.Reset()
+	if s.timings != nil { // no moving window without UI
+		s.timings.Reset()
+	}
+	s.summary.Reset()
+	s.errors.Reset()
 
 	for i := 0; i < len(s.responses.status); i++ {
 		s.responses.status[i].Store(0)
@@ -34,8 +59,57 @@ func (s *Stats) initializeTimingsBucket(buckets int) {
 	s.timings = NewMovingWindow(movingWindowsSize*screenRefreshFrequency, buckets)
 }
 
+// getSetRate returns the current set rate of the ticker
+func (s *Stats) getSetRate() float64 {
+	return math.Float64frombits(s.setRate.Load())
+}
+
+func (s *Stats) storeSetRate(rate float64) {
+	s.setRate.Store(math.Float64bits(rate))
+}
+
 func (s *Stats) getInFlightRequests() int64 {
 	sent := s.requestsSent.Load()
 	recv := s.responsesReceived.Load()
 	return sent - recv
 }
+
+// addGauges registers the run wide values in the metrics collector
+func addGauges(c *metrics.Collector) {
+	c.AddGauge("slapperx_in_flight_requests", "Requests sent and not yet finished.", func() float64 {
+		return float64(stats.getInFlightRequests())
+	})
+	c.AddGauge("slapperx_set_rate", "Set request rate per second.", func() float64 {
+		return stats.getSetRate()
+	})
+	c.AddGauge("slapperx_connections_open", "Open connections.", func() float64 {
+		current, _, _ := trgt.client.Connections()
+		return float64(current)
+	})
+	c.AddGauge("slapperx_connections_opened_total", "Opened connections.", func() float64 {
+		_, opened, _ := trgt.client.Connections()
+		return float64(opened)
+	})
+}
+
+// newPusher creates the pusher for the configured metric sinks, nil if there are none
+func newPusher(config *Config, c *metrics.Collector) (*metrics.Pusher, error) {
+	var sinks []metrics.Sink
+	if config.StatsD != "" {
+		statsD, err := metrics.NewStatsD(config.StatsD)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, statsD)
+	}
+	if config.Influx != "" {
+		sinks = append(sinks, metrics.NewInflux(config.Influx, os.Getenv("INFLUX_TOKEN")))
+	}
+	if config.OTLP != "" {
+		sinks = append(sinks, metrics.NewOTLP(config.OTLP))
+	}
+	if len(sinks) == 0 {
+		return nil, nil
+	}
+	return metrics.NewPusher(c, config.PushInterval, sinks...), nil
+}
@@ -0,0 +1,116 @@
+package slapperx
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// statusClass groups the responses in the histogram
+type statusClass int
+
+const (
+	class2xx   statusClass = iota // including informational responses
+	class3xx                      // redirects
+	class4xx                      // client errors
+	class5xx                      // server errors
+	classError                    // failed requests without response
+	statusClassCount
+	classAll statusClass = -1 // no filter by class
+)
+
+var statusClassNames = []string{"2xx", "3xx", "4xx", "5xx", "error"}
+
+// statusClassColors are the colors of the stacked histogram bars and the response counters
+var statusClassColors = []string{"\033[32m", "\033[36m", "\033[33m", "\033[31m", "\033[35m"}
+
+func (c statusClass) String() string {
+	if c == classAll {
+		return "all"
+	}
+	return statusClassNames[c]
+}
+
+// classOf returns the class of a status code, 0 for failed requests
+func classOf(status int) statusClass {
+	switch {
+	case status == 0:
+		return classError
+	case status < 300:
+		return class2xx
+	case status < 400:
+		return class3xx
+	case status < 500:
+		return class4xx
+	default:
+		return class5xx
+	}
+}
+
+// statusFilter selects the responses shown in the histogram, either a class or a single status code
+type statusFilter struct {
+	class  statusClass
+	status int // if > 0 only this status code is shown
+}
+
+var noStatusFilter = statusFilter{class: classAll}
+
+func (f statusFilter) matches(status int) bool {
+	if f.status > 0 {
+		return status == f.status
+	}
+	return f.class == classAll || classOf(status) == f.class
+}
+
+// next returns the filter of the next class, after the last class the filter is turned off
+func (f statusFilter) next() statusFilter {
+	if f.status > 0 || f.class == statusClassCount-1 {
+		return noStatusFilter
+	}
+	return statusFilter{class: f.class + 1}
+}
+
+func (f statusFilter) String() string {
+	if f.status > 0 {
+		return strconv.Itoa(f.status)
+	}
+	return f.class.String()
+}
+
+// parseStatusFilter parses all, a class like 4xx, error or a status code like 404
+func parseStatusFilter(text string) (statusFilter, error) {
+	text = strings.ToLower(text)
+	if text == "all" {
+		return noStatusFilter, nil
+	}
+	for c, name := range statusClassNames {
+		if text == name {
+			return statusFilter{class: statusClass(c)}, nil
+		}
+	}
+	status, err := strconv.Atoi(text)
+	if err != nil || status < 100 || status > 999 {
+		return noStatusFilter, fmt.Errorf("invalid filter %q, expected all, 2xx, 3xx, 4xx, 5xx, error or a status code", text)
+	}
+	return statusFilter{class: classOf(status), status: status}, nil
+}
+
+// CycleFilter shows the next status class in the histogram and switches to the histogram
+func (ui *UI) CycleFilter() {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+	ui.filter = ui.filter.next()
+	ui.view = viewHistogram
+	ui.showHelp = false
+	ui.clearScreen()
+}
+
+// SetFilter shows only the responses matching the filter in the histogram and switches to the histogram
+func (ui *UI) SetFilter(filter statusFilter) {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+	ui.filter = filter
+	ui.view = viewHistogram
+	ui.showHelp = false
+	ui.clearScreen()
+}
@@ -0,0 +1,85 @@
+package slapperx
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseStatusFilter(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected statusFilter
+		fails    bool
+	}{
+		{"all", noStatusFilter, false},
+		{"ALL", noStatusFilter, false},
+		{"2xx", statusFilter{class: class2xx}, false},
+		{"3xx", statusFilter{class: class3xx}, false},
+		{"4XX", statusFilter{class: class4xx}, false},
+		{"5xx", statusFilter{class: class5xx}, false},
+		{"error", statusFilter{class: classError}, false},
+		{"404", statusFilter{class: class4xx, status: 404}, false},
+		{"101", statusFilter{class: class2xx, status: 101}, false},
+		{"503", statusFilter{class: class5xx, status: 503}, false},
+		{"", noStatusFilter, true},
+		{"6xx", noStatusFilter, true},
+		{"errors", noStatusFilter, true},
+		{"99", noStatusFilter, true},
+		{"1000", noStatusFilter, true},
+		{"-200", noStatusFilter, true},
+		{"4o4", noStatusFilter, true},
+	}
+	for _, test := range tests {
+		filter, err := parseStatusFilter(test.text)
+		if (err != nil) != test.fails {
+			t.Errorf("%q: unexpected error %v", test.text, err)
+			continue
+		}
+		if filter != test.expected {
+			t.Errorf("%q: expected %+v, got %+v", test.text, test.expected, filter)
+		}
+	}
+}
+
+func TestStatusFilterMatches(t *testing.T) {
+	tests := []struct {
+		filter   string
+		expected []bool // matches of 0, 200, 302, 404, 500 and 503
+	}{
+		{"all", []bool{true, true, true, true, true, true}},
+		{"2xx", []bool{false, true, false, false, false, false}},
+		{"3xx", []bool{false, false, true, false, false, false}},
+		{"4xx", []bool{false, false, false, true, false, false}},
+		{"5xx", []bool{false, false, false, false, true, true}},
+		{"error", []bool{true, false, false, false, false, false}},
+		{"503", []bool{false, false, false, false, false, true}},
+	}
+	statuses := []int{0, 200, 302, 404, 500, 503}
+	for _, test := range tests {
+		filter, err := parseStatusFilter(test.filter)
+		if err != nil {
+			t.Fatalf("%q: unexpected error %v", test.filter, err)
+		}
+		for i, status := range statuses {
+			if filter.matches(status) != test.expected[i] {
+				t.Errorf("%q: expected match %v for status %d", test.filter, test.expected[i], status)
+			}
+		}
+	}
+}
+
+func TestStatusFilterNext(t *testing.T) {
+	filter := noStatusFilter
+	var names []string
+	for range statusClassCount + 2 {
+		filter = filter.next()
+		names = append(names, filter.String())
+	}
+	expected := []string{"2xx", "3xx", "4xx", "5xx", "error", "all", "2xx"}
+	if !slices.Equal(names, expected) {
+		t.Errorf("Expected %v, got %v", expected, names)
+	}
+	if next := (statusFilter{class: class4xx, status: 404}).next(); next != noStatusFilter {
+		t.Errorf("Expected a status code filter to be turned off, got %v", next)
+	}
+}
@@ -1,15 +1,13 @@
 package slapperx
 
 import (
-	"errors"
 	"fmt"
+	"github.com/s-macke/slapperx/src/httpfile"
+	"github.com/s-macke/slapperx/src/logformat"
 	"github.com/s-macke/slapperx/src/tracing"
 	"io"
-	"net"
 	"net/http"
-	"os"
 	"sync"
-	"syscall"
 	"time"
 )
 
@@ -17,28 +15,31 @@ type Targeter struct {
 	client   *tracing.Client
 	wg       sync.WaitGroup
 	idx      counter
-	requests []http.Request
+	requests *RequestSet
 
 	logFile *LogFile
 	result  chan ResultStruct
 
 	attackStartTime time.Time // time when the attack started
 
+	ticker      <-chan time.Time
+	workersMu   sync.Mutex
+	workersQuit []chan struct{} // one channel per running worker, closed to stop it
+
 	verbose bool
 }
 
 func NewTargeter(
-	requests *[]http.Request,
-	timeout time.Duration,
+	requests *[]httpfile.Request,
+	client *tracing.Client,
 	logFile *LogFile,
 	verbose bool,
 	resultStruct chan ResultStruct) *Targeter {
-	client := tracing.NewTracingClient(timeout)
 
 	trgt := &Targeter{
 		client:   client,
 		idx:      0,
-		requests: *requests,
+		requests: NewRequestSet(*requests),
 		logFile:  logFile,
 		verbose:  verbose,
 		result:   resultStruct,
@@ -49,21 +50,22 @@ func NewTargeter(
 
 func (trgt *Targeter) Close() {
 	trgt.wg.Wait()
+	trgt.client.Close()
 }
 
-func (trgt *Targeter) nextRequest() *http.Request {
-	idx := int(trgt.idx.Add(1))
-	request := trgt.requests[idx%len(trgt.requests)]
-	request.Body, _ = request.GetBody()
-	return &request
+// nextRequest returns the next enabled request, nil if all requests are disabled
+func (trgt *Targeter) nextRequest() *httpfile.Request {
+	return trgt.requests.next(trgt.idx.Add(1))
 }
 
 type AttackResponse struct {
-	status int
-	err    error
-	start  time.Time
-	end    time.Time
-	body   []byte
+	status  int
+	err     error
+	start   time.Time
+	end     time.Time
+	body    []byte
+	bytesIn int64
+	trace   *tracing.RequestTrace
 }
 
 func (trgt *Targeter) DoRequest(request *http.Request, doStoreBody bool) AttackResponse {
@@ -71,6 +73,7 @@ func (trgt *Targeter) DoRequest(request *http.Request, doStoreBody bool) AttackR
 		status: 0,
 		err:    nil,
 	}
+	request, attackResponse.trace = tracing.WithRequestTrace(request)
 	attackResponse.start = time.Now()
 
 	response, err := trgt.client.Do(request)
@@ -81,8 +84,9 @@ func (trgt *Targeter) DoRequest(request *http.Request, doStoreBody bool) AttackR
 		attackResponse.status = response.StatusCode
 		if doStoreBody {
 			attackResponse.body, err = io.ReadAll(response.Body)
+			attackResponse.bytesIn = int64(len(attackResponse.body))
 		} else {
-			_, err = io.ReadAll(response.Body)
+			attackResponse.bytesIn, err = io.Copy(io.Discard, response.Body)
 		}
 		if err != nil && trgt.verbose {
 			fmt.Println("Error:", request.Method, request.URL, err)
@@ -94,29 +98,25 @@ func (trgt *Targeter) DoRequest(request *http.Request, doStoreBody bool) AttackR
 	return attackResponse
 }
 
-func (trgt *Targeter) FillStats(request *http.Request, response AttackResponse,
-	currentSetRate float64, currentInFlightRequests int64) {
-	var dnsError *net.DNSError
+func (trgt *Targeter) FillStats(request *httpfile.Request, response AttackResponse,
+	currentSetRate float64, currentInFlightRequests int64, worker int) {
+	class := classifyError(response.err)
 	stats.responsesReceived.Add(1)
-	if response.err == nil {
+	switch class {
+	case errorClassNone:
 		stats.responses.status[response.status].Add(1)
-	} else {
-		switch {
-		case
-			errors.Is(response.err, io.EOF):
-			stats.responses.ErrorEof.Add(1)
-		case
-			errors.Is(response.err, syscall.ECONNREFUSED):
-			stats.responses.ErrorConnRefused.Add(1)
-		case
-			os.IsTimeout(response.err):
-			stats.responses.ErrorTimeout.Add(1)
-		case
-			errors.As(response.err, &dnsError):
-			stats.responses.ErrorNoSuchHost.Add(1)
-		default:
-			stats.responses.status[0].Add(1)
-		}
+	case errorClassProxy:
+		stats.responses.ErrorProxy.Add(1)
+	case errorClassEOF:
+		stats.responses.ErrorEof.Add(1)
+	case errorClassConnRefused:
+		stats.responses.ErrorConnRefused.Add(1)
+	case errorClassTimeout:
+		stats.responses.ErrorTimeout.Add(1)
+	case errorClassNoSuchHost:
+		stats.responses.ErrorNoSuchHost.Add(1)
+	default:
+		stats.responses.status[0].Add(1)
 	}
 
 	elapsed := response.end.Sub(response.start)
@@ -124,15 +124,12 @@ func (trgt *Targeter) FillStats(request *http.Request, response AttackResponse,
 	// to test the latency distribution
 	// elapsedMs = (math.Sin(elapsedMs)+1.1)*30. + math.Cos(float64(start.UnixMilli()/5000))*100 + 100.
 
+	record := trgt.newLogRecord(request, response, class, currentSetRate, currentInFlightRequests, worker)
+	stats.summary.Add(&record)
+	stats.metrics.Add(&record)
+	stats.errors.Add(&record)
 	if trgt.logFile != nil {
-		trgt.logFile.WriteString(
-			fmt.Sprintf("%s,%d,%d,%d,%d,%.1f\n",
-				response.start.Format("2006-01-02T15:04:05.999999999"),
-				response.start.Sub(trgt.attackStartTime).Milliseconds(),
-				elapsed.Milliseconds(),
-				response.status,
-				currentInFlightRequests,
-				currentSetRate))
+		trgt.logFile.Write(record)
 	}
 
 	if trgt.verbose {
@@ -148,32 +145,131 @@ func (trgt *Targeter) FillStats(request *http.Request, response AttackResponse,
 	}
 }
 
-func (trgt *Targeter) attack(ch <-chan time.Time) {
+// newLogRecord creates the log file entry of a finished request
+func (trgt *Targeter) newLogRecord(request *httpfile.Request, response AttackResponse, class errorClass,
+	currentSetRate float64, currentInFlightRequests int64, worker int) logformat.Record {
+	record := logformat.Record{
+		Timestamp:  response.start,
+		OffsetMs:   logformat.Milliseconds(response.start.Sub(trgt.attackStartTime)),
+		ElapsedMs:  logformat.Milliseconds(response.end.Sub(response.start)),
+		Status:     response.status,
+		InFlight:   currentInFlightRequests,
+		SetRate:    currentSetRate,
+		Name:       request.Name,
+		Method:     request.Method,
+		URL:        request.RawURL,
+		BytesIn:    response.bytesIn,
+		BytesOut:   max(request.ContentLength, 0),
+		ErrorClass: string(class),
+		Worker:     worker,
+	}
+	if response.err != nil {
+		record.Error = response.err.Error()
+	}
+	if response.trace != nil {
+		record.DNSMs = logformat.Milliseconds(response.trace.DNS())
+		record.ConnectMs = logformat.Milliseconds(response.trace.Connect())
+		record.TLSMs = logformat.Milliseconds(response.trace.TLS())
+		record.TTFBMs = logformat.Milliseconds(response.trace.TimeToFirstByte())
+		record.Reused = response.trace.Reused()
+	}
+	return record
+}
+
+// writeAnnotation writes the annotation to the log file
+func (trgt *Targeter) writeAnnotation(annotation Annotation) {
+	if trgt.logFile == nil {
+		return
+	}
+	trgt.logFile.Write(logformat.Record{
+		Timestamp:  annotation.Time,
+		OffsetMs:   logformat.Milliseconds(annotation.Time.Sub(trgt.attackStartTime)),
+		Annotation: annotation.Text,
+	})
+}
+
+func (trgt *Targeter) attack(worker int, ch <-chan time.Time, quit <-chan struct{}) {
 	for {
-		_, ok := <-ch
-		if !ok { // channel closed
+		select {
+		case <-quit:
 			return
+		case _, ok := <-ch:
+			if !ok { // channel closed
+				return
+			}
 		}
 		request := trgt.nextRequest()
+		if request == nil { // all requests are disabled
+			continue
+		}
 		stats.requestsSent.Add(1)
+		stats.metrics.Sent(worker, request.Name)
 
 		// Save the rate when the request started
-		currentSetRate := stats.currentSetRate
+		currentSetRate := stats.getSetRate()
 		currentInFlightRequests := stats.getInFlightRequests()
 
-		response := trgt.DoRequest(request, false)
-		trgt.FillStats(request, response, currentSetRate, currentInFlightRequests)
+		response := trgt.DoRequest(&request.Request, false)
+		trgt.FillStats(request, response, currentSetRate, currentInFlightRequests, worker)
 	}
 }
 
 func (trgt *Targeter) Start(workers uint, ticker <-chan time.Time) {
 	trgt.attackStartTime = time.Now()
-	// start attackers
-	for i := uint(0); i < workers; i++ {
+	trgt.ticker = ticker
+	trgt.AddWorkers(int(workers))
+}
+
+// AddWorkers starts n additional workers
+func (trgt *Targeter) AddWorkers(n int) {
+	trgt.workersMu.Lock()
+	defer trgt.workersMu.Unlock()
+	trgt.addWorkers(n)
+}
+
+// RemoveWorkers stops n workers, but keeps at least one. Requests in flight are finished.
+func (trgt *Targeter) RemoveWorkers(n int) {
+	trgt.workersMu.Lock()
+	defer trgt.workersMu.Unlock()
+	trgt.removeWorkers(n)
+}
+
+// SetWorkers starts or stops workers until n are running, at least one
+func (trgt *Targeter) SetWorkers(n int) {
+	trgt.workersMu.Lock()
+	defer trgt.workersMu.Unlock()
+	if current := len(trgt.workersQuit); n > current {
+		trgt.addWorkers(n - current)
+	} else {
+		trgt.removeWorkers(current - n)
+	}
+}
+
+// Workers returns the number of running workers
+func (trgt *Targeter) Workers() int {
+	trgt.workersMu.Lock()
+	defer trgt.workersMu.Unlock()
+	return len(trgt.workersQuit)
+}
+
+func (trgt *Targeter) addWorkers(n int) {
+	for range n {
+		quit := make(chan struct{})
+		worker := len(trgt.workersQuit)
+		trgt.workersQuit = append(trgt.workersQuit, quit)
 		trgt.wg.Add(1)
 		go func() {
 			defer trgt.wg.Done()
-			trgt.attack(ticker)
+			trgt.attack(worker, trgt.ticker, quit)
 		}()
 	}
 }
+
+func (trgt *Targeter) removeWorkers(n int) {
+	n = min(n, len(trgt.workersQuit)-1)
+	for range n {
+		last := len(trgt.workersQuit) - 1
+		close(trgt.workersQuit[last])
+		trgt.workersQuit = trgt.workersQuit[:last]
+	}
+}
@@ -0,0 +1,154 @@
+package slapperx
+
+import (
+	"fmt"
+	"github.com/s-macke/slapperx/src/report"
+	terminal "golang.org/x/term"
+	"os"
+	"sync"
+	"time"
+)
+
+// ThresholdMonitor evaluates the thresholds every second during the run and once at the end
+type ThresholdMonitor struct {
+	thresholds []*report.Threshold
+	duration   time.Duration // length of the run, 0 if not bounded
+	abort      bool          // stop the run once a threshold can no longer be met
+
+	start   time.Time
+	mu      sync.Mutex
+	failed  int    // failed thresholds at the last evaluation
+	aborted string // threshold which aborted the run
+	done    chan bool
+}
+
+func NewThresholdMonitor(exprs []string, duration time.Duration, abort bool) (*ThresholdMonitor, error) {
+	m := &ThresholdMonitor{
+		duration: duration,
+		abort:    abort,
+		done:     make(chan bool),
+	}
+	for _, expr := range exprs {
+		threshold, err := report.ParseThreshold(expr)
+		if err != nil {
+			return nil, err
+		}
+		m.thresholds = append(m.thresholds, threshold)
+	}
+	return m, nil
+}
+
+// Start evaluates the thresholds every second and calls stop if a threshold can no longer be met
+func (m *ThresholdMonitor) Start(stop func()) {
+	m.start = time.Now()
+	if len(m.thresholds) == 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				m.evaluate()
+				if threshold := m.irrecoverable(); threshold != "" {
+					m.mu.Lock()
+					m.aborted = threshold
+					m.mu.Unlock()
+					stop()
+					return
+				}
+			case <-m.done:
+				return
+			}
+		}
+	}()
+}
+
+func (m *ThresholdMonitor) evaluate() []report.ThresholdResult {
+	results := make([]report.ThresholdResult, len(m.thresholds))
+	failed := 0
+	for i, threshold := range m.thresholds {
+		results[i] = threshold.Evaluate(stats.summary)
+		if !results[i].Passed {
+			failed++
+		}
+	}
+	m.mu.Lock()
+	m.failed = failed
+	m.mu.Unlock()
+	return results
+}
+
+// irrecoverable returns the first threshold which can no longer be met, if the run is aborted early
+func (m *ThresholdMonitor) irrecoverable() string {
+	if !m.abort {
+		return ""
+	}
+	var expected int64
+	if m.duration > 0 {
+		remaining := max(m.duration-time.Since(m.start), 0)
+		expected = stats.requestsSent.Load() + int64(stats.getSetRate()*remaining.Seconds())
+	}
+	for _, threshold := range m.thresholds {
+		if threshold.Irrecoverable(stats.summary, expected) {
+			return threshold.Expr
+		}
+	}
+	return ""
+}
+
+// Status returns the number of thresholds and how many of them failed at the last evaluation
+func (m *ThresholdMonitor) Status() (total int, failed int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.thresholds), m.failed
+}
+
+// Finish evaluates the thresholds at the end of the run, prints the results and writes the JUnit file.
+// It returns false if a threshold failed.
+func (m *ThresholdMonitor) Finish(junitFile string) bool {
+	close(m.done)
+	results := m.evaluate()
+	passed := true
+	if len(results) > 0 {
+		fmt.Println("Thresholds:")
+	}
+	// no color codes in CI logs
+	pass, fail := "PASS", "FAIL"
+	if terminal.IsTerminal(int(os.Stdout.Fd())) {
+		pass, fail = "\033[32mPASS\033[0m", "\033[31mFAIL\033[0m"
+	}
+	for _, result := range results {
+		status := pass
+		if !result.Passed {
+			status = fail
+			passed = false
+		}
+		fmt.Printf("  %s %-20s value: %.4g limit: %.4g\n", status, result.Threshold, result.Value, result.Limit)
+	}
+	m.mu.Lock()
+	if m.aborted != "" {
+		fmt.Printf("Aborted, threshold %s can no longer be met\n", m.aborted)
+	}
+	m.mu.Unlock()
+
+	if junitFile != "" {
+		if err := writeJUnit(junitFile, results, m.start, time.Since(m.start)); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Failed to write JUnit file: %v\n", err)
+		}
+	}
+	return passed
+}
+
+func writeJUnit(path string, results []report.ThresholdResult, start time.Time, duration time.Duration) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = report.WriteJUnit(file, results, start, duration)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
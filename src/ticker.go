@@ -1,6 +1,7 @@
 package slapperx
 
 import (
+	"sync/atomic"
 	"time"
 )
 
@@ -10,6 +11,7 @@ type Ticker struct {
 	tickDuration    time.Duration
 	rateChangerChan chan float64
 	done            chan bool
+	paused          atomic.Bool
 }
 
 // NewTicker creates a new ticker instance with a given rate and ramp-up time.
@@ -46,21 +48,22 @@ func (t *Ticker) Start() <-chan time.Time {
 
 	// start main workers
 	go func() {
-		stats.currentSetRate = t.rate
+		stats.storeSetRate(t.rate)
 		tck := time.NewTicker(t.tickDuration)
 
 		for {
 			select {
 			case newRate := <-t.rateChangerChan:
-				stats.currentSetRate = newRate
+				stats.storeSetRate(newRate)
 				if newRate > 0 {
 					t.setTickDuration(newRate)
 					tck.Reset(t.tickDuration)
-				} else {
-					stats.currentSetRate = newRate
 				}
 
 			case onTick := <-tck.C:
+				if t.paused.Load() {
+					break
+				}
 				for i := int64(0); i < t.multiplier; i++ {
 					ticker <- onTick
 				}
@@ -74,6 +77,19 @@ func (t *Ticker) Start() <-chan time.Time {
 	return ticker
 }
 
+// Pause stops sending ticks until Resume is called
+func (t *Ticker) Pause() {
+	t.paused.Store(true)
+}
+
+func (t *Ticker) Resume() {
+	t.paused.Store(false)
+}
+
+func (t *Ticker) IsPaused() bool {
+	return t.paused.Load()
+}
+
 func (t *Ticker) Stop() {
 	t.done <- true
 }
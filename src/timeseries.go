@@ -0,0 +1,221 @@
+package slapperx
+
+import (
+	"fmt"
+	"github.com/s-macke/slapperx/src/chart"
+	"github.com/s-macke/slapperx/src/metrics"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	timeSeriesLabelWidth = 10
+
+	colorP50     = "\033[32m"
+	colorP90     = "\033[33m"
+	colorP99     = "\033[31m"
+	colorSetRate = "\033[90m"
+	colorRate    = "\033[96m"
+	colorErrors  = "\033[91m"
+	colorMarker  = "\033[95m"
+)
+
+// timeSample contains the values of one second. The latencies are NaN if no request finished.
+type timeSample struct {
+	p50, p90, p99 float64 // ms
+	rate          float64 // finished requests per second
+	setRate       float64
+	errors        float64 // failed requests per second
+}
+
+// TimeSeries collects the latency and rate of every second of the run
+type TimeSeries struct {
+	interval *metrics.Interval
+
+	mu            sync.Mutex
+	start         time.Time // the first sample ends one second later
+	samples       []timeSample
+	endpointRates map[string]float64 // finished requests per second of every request name in the last second
+}
+
+func NewTimeSeries(interval *metrics.Interval) *TimeSeries {
+	return &TimeSeries{interval: interval}
+}
+
+// collect adds a sample every second until done is closed
+func (ts *TimeSeries) collect(done <-chan bool) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	last := time.Now()
+	ts.mu.Lock()
+	ts.start = last
+	ts.mu.Unlock()
+	for {
+		var now time.Time
+		select {
+		case now = <-ticker.C:
+		case <-done:
+			return
+		}
+		summary := ts.interval.Take()
+		overall := summary.Overall
+		seconds := now.Sub(last).Seconds()
+		endpointRates := make(map[string]float64, len(summary.Endpoints))
+		for _, e := range summary.Endpoints {
+			endpointRates[e.Name] = float64(e.Requests) / seconds
+		}
+		sample := timeSample{
+			p50:     math.NaN(),
+			p90:     math.NaN(),
+			p99:     math.NaN(),
+			rate:    float64(overall.Requests) / seconds,
+			setRate: stats.getSetRate(),
+			errors:  float64(overall.Errors) / seconds,
+		}
+		if overall.Requests > 0 {
+			sample.p50, sample.p90, sample.p99 = overall.Latency.P50Ms, overall.Latency.P90Ms, overall.Latency.P99Ms
+		}
+		ts.mu.Lock()
+		ts.samples = append(ts.samples, sample)
+		ts.endpointRates = endpointRates
+		ts.mu.Unlock()
+		last = now
+	}
+}
+
+// series returns the values of all samples, reduced to n values
+func (ts *TimeSeries) series(n int, value func(s *timeSample) float64) []float64 {
+	ts.mu.Lock()
+	values := make([]float64, len(ts.samples))
+	for i := range ts.samples {
+		values[i] = value(&ts.samples[i])
+	}
+	ts.mu.Unlock()
+	return chart.Downsample(values, n)
+}
+
+// markers returns the positions of the annotations in the series reduced to n values
+func (ts *TimeSeries) markers(n int, annotations []Annotation) []int {
+	ts.mu.Lock()
+	start, length := ts.start, len(ts.samples)
+	ts.mu.Unlock()
+	if length == 0 {
+		return nil
+	}
+	positions := make([]int, 0, len(annotations))
+	for _, a := range annotations {
+		i := min(max(int(a.Time.Sub(start)/time.Second), 0), length-1)
+		positions = append(positions, chart.DownsampleIndex(i, length, n))
+	}
+	return positions
+}
+
+// endpointRate returns the finished requests per second of the request name in the last second
+func (ts *TimeSeries) endpointRate(name string) float64 {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return ts.endpointRates[name]
+}
+
+func (ts *TimeSeries) len() int {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return len(ts.samples)
+}
+
+func maxValue(series ...[]float64) float64 {
+	m := 0.
+	for _, values := range series {
+		for _, v := range values {
+			if !math.IsNaN(v) {
+				m = max(m, v)
+			}
+		}
+	}
+	return m
+}
+
+// drawChart writes the rows of the canvas with the axis labels
+func drawChart(sb *strings.Builder, canvas *chart.Canvas, rows int, maxY float64, unit string) {
+	for row := 0; row < rows; row++ {
+		label := ""
+		switch row {
+		case 0:
+			label = fmt.Sprintf("%.4g %s", maxY, unit)
+		case rows - 1:
+			label = fmt.Sprintf("0 %s", unit)
+		case rows / 2:
+			label = fmt.Sprintf("%.4g %s", maxY/2, unit)
+		}
+		_, _ = fmt.Fprintf(sb, "%*s %s\r\n", timeSeriesLabelWidth-1, label, canvas.Row(row))
+	}
+}
+
+// legend writes the title and the colored names and returns the number of characters written
+func legend(sb *strings.Builder, title string, entries ...string) int {
+	_, _ = fmt.Fprintf(sb, "%-*s", timeSeriesLabelWidth, title)
+	width := max(timeSeriesLabelWidth, len(title))
+	for i := 0; i+1 < len(entries); i += 2 {
+		_, _ = fmt.Fprintf(sb, "%s■ %s\033[0m  ", entries[i], entries[i+1])
+		width += len(entries[i+1]) + 4
+	}
+	return width
+}
+
+// annotationLegend writes the annotations with their time since the start of the run in at most n characters
+func (ui *UI) annotationLegend(sb *strings.Builder, annotations []Annotation, n int) {
+	if len(annotations) == 0 || n < 10 {
+		return
+	}
+	texts := make([]string, len(annotations))
+	for i, a := range annotations {
+		texts[i] = fmt.Sprintf("%ds %s", int(a.Time.Sub(ui.start).Seconds()), a.Text)
+	}
+	_, _ = fmt.Fprintf(sb, "%s┊ %s\033[0m", colorMarker, truncate(strings.Join(texts, ", "), n-2))
+}
+
+// drawTimeSeries draws the p50, p90 and p99 latency and the set and achieved rate with the errors
+// of the whole run below the header
+func (ui *UI) drawTimeSeries(sb *strings.Builder) {
+	width := ui.plotWidth - timeSeriesLabelWidth - 1
+	latencyRows := (ui.plotHeight - 3) / 2
+	rateRows := ui.plotHeight - 3 - latencyRows
+
+	annotations := stats.annotations.List()
+	latency := chart.NewCanvas(width, latencyRows)
+	for _, x := range ui.series.markers(latency.Width(), annotations) {
+		latency.VLine(x, colorMarker)
+	}
+	p50 := ui.series.series(latency.Width(), func(s *timeSample) float64 { return s.p50 })
+	p90 := ui.series.series(latency.Width(), func(s *timeSample) float64 { return s.p90 })
+	p99 := ui.series.series(latency.Width(), func(s *timeSample) float64 { return s.p99 })
+	maxLatency := chart.NiceMax(maxValue(p50, p90, p99))
+	latency.Plot(p99, maxLatency, colorP99)
+	latency.Plot(p90, maxLatency, colorP90)
+	latency.Plot(p50, maxLatency, colorP50)
+
+	rates := chart.NewCanvas(width, rateRows)
+	for _, x := range ui.series.markers(rates.Width(), annotations) {
+		rates.VLine(x, colorMarker)
+	}
+	setRate := ui.series.series(rates.Width(), func(s *timeSample) float64 { return s.setRate })
+	rate := ui.series.series(rates.Width(), func(s *timeSample) float64 { return s.rate })
+	errors := ui.series.series(rates.Width(), func(s *timeSample) float64 { return s.errors })
+	maxRate := chart.NiceMax(maxValue(setRate, rate))
+	rates.Plot(setRate, maxRate, colorSetRate)
+	rates.Plot(rate, maxRate, colorRate)
+	rates.Plot(errors, maxRate, colorErrors)
+
+	used := legend(sb, "latency", colorP50, "p50", colorP90, "p90", colorP99, "p99")
+	ui.annotationLegend(sb, annotations, ui.plotWidth-1-used)
+	_, _ = fmt.Fprint(sb, "\033[K\r\n")
+	drawChart(sb, latency, latencyRows, maxLatency, "ms")
+	legend(sb, "rate", colorSetRate, "set", colorRate, "achieved", colorErrors, "errors")
+	_, _ = fmt.Fprint(sb, "\033[K\r\n")
+	drawChart(sb, rates, rateRows, maxRate, "/s")
+
+	duration := (time.Duration(ui.series.len()) * time.Second).String()
+	_, _ = fmt.Fprintf(sb, "%*s%-*s%s\033[K", timeSeriesLabelWidth, "", max(width-len(duration), 0), "0s", duration)
+}
@@ -0,0 +1,37 @@
+package tracing
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// connectionPool spreads requests round-robin over a fixed number of transports,
+// each of them limited to a single connection per host
+type connectionPool struct {
+	transports []*http.Transport
+	idx        atomic.Uint64
+}
+
+func newConnectionPool(transport *http.Transport, connections int) *connectionPool {
+	pool := &connectionPool{
+		transports: make([]*http.Transport, connections),
+	}
+	for i := range pool.transports {
+		t := transport.Clone()
+		t.MaxConnsPerHost = 1
+		t.MaxIdleConnsPerHost = 1
+		pool.transports[i] = t
+	}
+	return pool
+}
+
+func (p *connectionPool) RoundTrip(req *http.Request) (*http.Response, error) {
+	idx := p.idx.Add(1)
+	return p.transports[idx%uint64(len(p.transports))].RoundTrip(req)
+}
+
+func (p *connectionPool) CloseIdleConnections() {
+	for _, t := range p.transports {
+		t.CloseIdleConnections()
+	}
+}
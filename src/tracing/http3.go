@@ -0,0 +1,107 @@
+package tracing
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+)
+
+func (t *Client) newHTTP3Transport() *http3.Transport {
+	return &http3.Transport{
+		TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: true,
+			// session tickets are required for 0-RTT resumption
+			ClientSessionCache: tls.NewLRUClientSessionCache(100),
+		},
+		QUICConfig: &quic.Config{
+			KeepAlivePeriod: 30 * time.Second,
+		},
+		Dial: t.DialQUIC,
+	}
+}
+
+// DialQUIC opens a QUIC connection on its own UDP socket and tracks it the same way as DialContext.
+// Like DialContext it tries the resolved addresses in turn until one of them succeeds.
+func (t *Client) DialQUIC(ctx context.Context, address string, tlsCfg *tls.Config, cfg *quic.Config) (quic.EarlyConnection, error) {
+	start := time.Now()
+	addrs, err := t.resolver.resolve(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	for _, addr := range addrs {
+		var conn quic.EarlyConnection
+		conn, err = t.dialQUICAddr(ctx, addr, tlsCfg, cfg, start)
+		if err == nil {
+			return conn, nil
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, err
+}
+
+func (t *Client) dialQUICAddr(ctx context.Context, address string, tlsCfg *tls.Config, cfg *quic.Config, start time.Time) (quic.EarlyConnection, error) {
+	udpAddr, err := net.ResolveUDPAddr("udp", address)
+	if err != nil {
+		return nil, err
+	}
+	var localAddr *net.UDPAddr
+	source, err := t.nextSourceAddr(address)
+	if err != nil {
+		return nil, err
+	}
+	if source != nil {
+		localAddr = &net.UDPAddr{IP: source.ip}
+	}
+	udpConn, err := net.ListenUDP("udp", localAddr)
+	if err != nil {
+		return nil, err
+	}
+	tr := &quic.Transport{Conn: udpConn}
+	conn, err := tr.DialEarly(ctx, udpAddr, tlsCfg, cfg)
+	if err != nil {
+		// quic-go does not close a Conn it was handed
+		_ = tr.Close()
+		_ = udpConn.Close()
+		return nil, err
+	}
+	atomic.AddInt32(&t.openedConnections, 1)
+	atomic.AddInt32(&t.CurrentConnections, 1)
+	source.connectionOpened()
+
+	go func() {
+		select {
+		case <-conn.HandshakeComplete():
+			atomic.AddInt64(&t.quicHandshakes, 1)
+			atomic.AddInt64(&t.quicHandshakeTime, int64(time.Since(start)))
+			if conn.ConnectionState().Used0RTT {
+				atomic.AddInt64(&t.quic0RTT, 1)
+			}
+		case <-conn.Context().Done():
+		}
+		<-conn.Context().Done()
+		_ = tr.Close()
+		_ = udpConn.Close()
+		atomic.AddInt32(&t.closedConnections, 1)
+		atomic.AddInt32(&t.CurrentConnections, -1)
+		source.connectionClosed()
+	}()
+	return conn, nil
+}
+
+// QUICStats returns the number of completed QUIC handshakes, their average duration
+// and how many connections were resumed with 0-RTT
+func (t *Client) QUICStats() (handshakes int64, avgHandshake time.Duration, zeroRTT int64) {
+	handshakes = atomic.LoadInt64(&t.quicHandshakes)
+	zeroRTT = atomic.LoadInt64(&t.quic0RTT)
+	if handshakes > 0 {
+		avgHandshake = time.Duration(atomic.LoadInt64(&t.quicHandshakeTime) / handshakes)
+	}
+	return
+}
@@ -0,0 +1,215 @@
+package tracing
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+)
+
+func selfSignedCertificate(t *testing.T) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// startHTTP3Server starts an in-process HTTP/3 server on the loopback interface and returns its address
+func startHTTP3Server(t *testing.T) string {
+	udpConn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("Failed to listen on UDP: %v", err)
+	}
+	server := &http3.Server{
+		TLSConfig: http3.ConfigureTLSConfig(&tls.Config{
+			Certificates: []tls.Certificate{selfSignedCertificate(t)},
+		}),
+		QUICConfig: &quic.Config{Allow0RTT: true},
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+		}),
+	}
+	go func() {
+		_ = server.Serve(udpConn)
+	}()
+	t.Cleanup(func() {
+		_ = server.Close()
+		_ = udpConn.Close()
+	})
+	return udpConn.LocalAddr().String()
+}
+
+func doGet(t *testing.T, client *Client, url string) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusTeapot {
+		t.Errorf("Expected status %d, got %d", http.StatusTeapot, resp.StatusCode)
+	}
+	if resp.ProtoMajor != 3 {
+		t.Errorf("Expected HTTP/3 response, got %s", resp.Proto)
+	}
+}
+
+func waitFor(t *testing.T, what string, condition func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timeout waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestHTTP3Client(t *testing.T) {
+	url := "https://" + startHTTP3Server(t) + "/"
+	client := newTestClient(t, Config{Timeout: 5 * time.Second, HTTP3: true})
+	defer client.Close()
+
+	if !client.IsHTTP3() {
+		t.Fatalf("Expected client in HTTP/3 mode")
+	}
+
+	doGet(t, client, url)
+	doGet(t, client, url)
+
+	waitFor(t, "handshake", func() bool {
+		handshakes, _, _ := client.QUICStats()
+		return handshakes == 1
+	})
+	handshakes, avgHandshake, zeroRTT := client.QUICStats()
+	if handshakes != 1 {
+		t.Errorf("Expected connection to be reused, got %d handshakes", handshakes)
+	}
+	if avgHandshake <= 0 {
+		t.Errorf("Expected positive handshake duration, got %s", avgHandshake)
+	}
+	if zeroRTT != 0 {
+		t.Errorf("Expected no 0-RTT on first connection, got %d", zeroRTT)
+	}
+	if atomic.LoadInt32(&client.CurrentConnections) != 1 {
+		t.Errorf("Expected 1 open connection, got %d", client.CurrentConnections)
+	}
+}
+
+func TestHTTP3ClientZeroRTT(t *testing.T) {
+	url := "https://" + startHTTP3Server(t) + "/"
+	client := newTestClient(t, Config{Timeout: 5 * time.Second, HTTP3: true})
+	defer client.Close()
+
+	doGet(t, client, url)
+	// wait for the session ticket before the connection is dropped
+	time.Sleep(100 * time.Millisecond)
+	client.CloseIdleConnections()
+	waitFor(t, "connection close", func() bool {
+		return atomic.LoadInt32(&client.closedConnections) == 1
+	})
+
+	doGet(t, client, url)
+	waitFor(t, "second handshake", func() bool {
+		handshakes, _, _ := client.QUICStats()
+		return handshakes == 2
+	})
+	if _, _, zeroRTT := client.QUICStats(); zeroRTT != 1 {
+		t.Errorf("Expected resumed connection to use 0-RTT, got %d", zeroRTT)
+	}
+}
+
+func TestHTTP3ClientConnectionError(t *testing.T) {
+	client := newTestClient(t, Config{Timeout: 500 * time.Millisecond, HTTP3: true})
+	defer client.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, "https://127.0.0.1:1/", nil)
+	if _, err := client.Do(req); err == nil {
+		t.Errorf("Expected error for unreachable server")
+	}
+	if atomic.LoadInt32(&client.CurrentConnections) != 0 {
+		t.Errorf("Expected no open connections, got %d", client.CurrentConnections)
+	}
+}
+
+func openFileCount(t *testing.T) int {
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		t.Skipf("Cannot count open files: %v", err)
+	}
+	return len(entries)
+}
+
+func TestHTTP3ClientReleasesSockets(t *testing.T) {
+	url := "https://" + startHTTP3Server(t) + "/"
+	client := newTestClient(t, Config{Timeout: 500 * time.Millisecond, HTTP3: true})
+	defer client.Close()
+
+	before := openFileCount(t)
+	unreachable, _ := http.NewRequest(http.MethodGet, "https://127.0.0.1:1/", nil)
+	for range 10 {
+		_, _ = client.Do(unreachable)
+	}
+	for i := range 10 {
+		doGet(t, client, url)
+		client.CloseIdleConnections()
+		waitFor(t, "connection close", func() bool {
+			return atomic.LoadInt32(&client.closedConnections) == int32(i+1)
+		})
+	}
+	if after := openFileCount(t); after > before {
+		t.Errorf("Expected UDP sockets to be closed, %d files leaked", after-before)
+	}
+}
+
+func TestHTTP3ClientUnsupportedOptions(t *testing.T) {
+	configs := []Config{
+		{HTTP3: true, Proxy: "http://127.0.0.1:3128"},
+		{HTTP3: true, UnixSocket: "/tmp/server.sock"},
+		{HTTP3: true, Connections: 4},
+	}
+	for _, config := range configs {
+		if _, err := NewTracingClient(config); err == nil {
+			t.Errorf("Expected error for %+v", config)
+		}
+	}
+}
+
+func TestHTTP3ClientResolveFallback(t *testing.T) {
+	_, port, _ := net.SplitHostPort(startHTTP3Server(t))
+	config := Config{Timeout: 5 * time.Second, HTTP3: true, KeepAlive: true}
+	// without an IPv6 source address the first address fails and the next one has to be tried
+	config.SourceAddrs = []net.IP{net.IPv4(127, 0, 0, 1)}
+	config.Resolve = []string{"backend.invalid:" + port + ":[::1],127.0.0.1"}
+	client := newTestClient(t, config)
+	defer client.Close()
+
+	for range 2 {
+		doGet(t, client, "https://backend.invalid:"+port+"/")
+		client.CloseIdleConnections()
+	}
+}
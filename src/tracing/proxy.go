@@ -0,0 +1,148 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// ProxyError is returned when the proxy rejects a CONNECT request
+type ProxyError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *ProxyError) Error() string {
+	return fmt.Sprintf("proxy rejected tunnel: %s", e.Status)
+}
+
+// IsProxyError returns true if the request failed while connecting to or through the proxy
+func IsProxyError(err error) bool {
+	var proxyError *ProxyError
+	if errors.As(err, &proxyError) {
+		return true
+	}
+	var opError *net.OpError
+	if errors.As(err, &opError) {
+		return opError.Op == "proxyconnect" || strings.HasPrefix(opError.Op, "socks")
+	}
+	return false
+}
+
+// newProxyFunc returns the proxy selection function. Without an explicit proxy
+// the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables are used.
+func newProxyFunc(proxy string) (func(*url.URL) (*url.URL, error), error) {
+	if proxy == "" {
+		return httpproxy.FromEnvironment().ProxyFunc(), nil
+	}
+	proxyURL, err := url.Parse(proxy)
+	if err != nil {
+		return nil, errors.New("invalid proxy URL: " + err.Error())
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, errors.New("unsupported proxy scheme: " + proxyURL.Scheme)
+	}
+	if proxyURL.Host == "" {
+		return nil, errors.New("proxy URL must have a host")
+	}
+	noProxy := os.Getenv("NO_PROXY")
+	if noProxy == "" {
+		noProxy = os.Getenv("no_proxy")
+	}
+	config := httpproxy.Config{
+		HTTPProxy:  proxy,
+		HTTPSProxy: proxy,
+		NoProxy:    noProxy,
+	}
+	proxyFunc := config.ProxyFunc()
+	return func(target *url.URL) (*url.URL, error) {
+		// httpproxy never proxies loopback targets, but an explicit proxy is used for them too
+		if host := target.Hostname(); isLoopback(host) && !matchesNoProxy(noProxy, host) {
+			return proxyURL, nil
+		}
+		return proxyFunc(target)
+	}, nil
+}
+
+// isLoopback returns true for localhost and loopback addresses
+func isLoopback(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// matchesNoProxy returns true if the host is excluded by a NO_PROXY entry, which is either *,
+// the host itself or a CIDR range containing it
+func matchesNoProxy(noProxy string, host string) bool {
+	ip := net.ParseIP(host)
+	for _, entry := range strings.Split(noProxy, ",") {
+		entry = strings.Trim(strings.TrimSpace(entry), "[]")
+		if entry == "*" || strings.EqualFold(entry, host) {
+			return true
+		}
+		if _, network, err := net.ParseCIDR(entry); err == nil && ip != nil && network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// proxy selects the proxy for a request and remembers its address,
+// so that DialContext can count the connections to the proxy
+func (t *Client) proxy(req *http.Request) (*url.URL, error) {
+	if _, ok := t.unixSocketPath(canonicalAddr(req.URL)); ok {
+		return nil, nil // Unix domain sockets are always connected directly
+	}
+	proxyURL, err := t.proxyFunc(req.URL)
+	if proxyURL != nil {
+		t.proxyAddrs.Store(canonicalAddr(proxyURL), true)
+	}
+	return proxyURL, err
+}
+
+// onProxyConnectResponse counts the established CONNECT tunnels and turns a rejected tunnel into a ProxyError
+func (t *Client) onProxyConnectResponse(_ context.Context, _ *url.URL, _ *http.Request, resp *http.Response) error {
+	if resp.StatusCode != http.StatusOK {
+		return &ProxyError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+	atomic.AddInt64(&t.proxyTunnels, 1)
+	return nil
+}
+
+func (t *Client) isProxyAddr(address string) bool {
+	_, ok := t.proxyAddrs.Load(address)
+	return ok
+}
+
+// ProxyStats returns the number of connections to the proxy and the number of established CONNECT tunnels
+func (t *Client) ProxyStats() (connections int64, tunnels int64) {
+	return atomic.LoadInt64(&t.proxyConnections), atomic.LoadInt64(&t.proxyTunnels)
+}
+
+// canonicalAddr returns host:port of the URL with the default port of the scheme
+func canonicalAddr(u *url.URL) string {
+	port := u.Port()
+	if port == "" {
+		switch u.Scheme {
+		case "https":
+			port = "443"
+		case "socks5", "socks5h":
+			port = "1080"
+		default:
+			port = "80"
+		}
+	}
+	return net.JoinHostPort(u.Hostname(), port)
+}
@@ -0,0 +1,145 @@
+package tracing
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// startProxy starts a forward proxy which answers plain HTTP requests itself and rejects all CONNECT tunnels
+func startProxy(t *testing.T) string {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodConnect {
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		_, _ = io.WriteString(w, r.URL.Host)
+	}))
+	t.Cleanup(proxy.Close)
+	return proxy.URL
+}
+
+func newProxyClient(t *testing.T, proxy string) *Client {
+	config := newTestConfig()
+	config.Proxy = proxy
+	return newTestClient(t, config)
+}
+
+func TestHTTPProxy(t *testing.T) {
+	t.Setenv("NO_PROXY", "")
+	client := newProxyClient(t, startProxy(t))
+	defer client.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, "http://target.invalid/", nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if string(body) != "target.invalid" {
+		t.Errorf("Expected request to be answered by the proxy, got %q", body)
+	}
+	if connections, tunnels := client.ProxyStats(); connections != 1 || tunnels != 0 {
+		t.Errorf("Expected 1 proxy connection and no tunnel, got %d and %d", connections, tunnels)
+	}
+}
+
+func TestHTTPProxyRejectsTunnel(t *testing.T) {
+	t.Setenv("NO_PROXY", "")
+	client := newProxyClient(t, startProxy(t))
+	defer client.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, "https://target.invalid/", nil)
+	_, err := client.Do(req)
+	var proxyError *ProxyError
+	if !errors.As(err, &proxyError) || proxyError.StatusCode != http.StatusProxyAuthRequired {
+		t.Fatalf("Expected ProxyError with status 407, got %v", err)
+	}
+	if !IsProxyError(err) {
+		t.Errorf("Expected error to be classified as proxy error")
+	}
+}
+
+func TestNoProxy(t *testing.T) {
+	t.Setenv("NO_PROXY", "target.invalid")
+	client := newProxyClient(t, startProxy(t))
+	defer client.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, "http://target.invalid/", nil)
+	_, err := client.Do(req)
+	if err == nil || IsProxyError(err) {
+		t.Errorf("Expected direct connection to fail without proxy error, got %v", err)
+	}
+	if connections, _ := client.ProxyStats(); connections != 0 {
+		t.Errorf("Expected no proxy connection, got %d", connections)
+	}
+}
+
+func TestProxyLoopback(t *testing.T) {
+	tests := []struct {
+		noProxy string
+		target  string
+		proxied bool
+	}{
+		{"", "http://127.0.0.1:1/", true},
+		{"", "http://localhost:1/", true},
+		{"", "http://[::1]:1/", true},
+		{"localhost", "http://localhost:1/", false},
+		{"127.0.0.0/8", "http://127.0.0.1:1/", false},
+		{"::1", "http://[::1]:1/", false},
+		{"*", "http://127.0.0.1:1/", false},
+		{"other.invalid", "http://127.0.0.1:1/", true},
+	}
+	proxy := startProxy(t)
+	for _, test := range tests {
+		t.Setenv("NO_PROXY", test.noProxy)
+		client := newProxyClient(t, proxy)
+		req, _ := http.NewRequest(http.MethodGet, test.target, nil)
+		resp, err := client.Do(req)
+		if err == nil {
+			_ = resp.Body.Close()
+		}
+		if connections, _ := client.ProxyStats(); (connections == 1) != test.proxied {
+			t.Errorf("%s with NO_PROXY=%q: expected proxied %v, got %d proxy connections and error %v",
+				test.target, test.noProxy, test.proxied, connections, err)
+		}
+		client.Close()
+	}
+}
+
+func TestUnreachableProxy(t *testing.T) {
+	for _, proxy := range []string{"http://127.0.0.1:1", "socks5://127.0.0.1:1"} {
+		client := newProxyClient(t, proxy)
+		req, _ := http.NewRequest(http.MethodGet, "http://target.invalid/", nil)
+		_, err := client.Do(req)
+		if !IsProxyError(err) {
+			t.Errorf("%s: expected proxy error, got %v", proxy, err)
+		}
+		client.Close()
+	}
+}
+
+func TestInvalidProxy(t *testing.T) {
+	for _, proxy := range []string{"ftp://proxy:21", "http://", "://"} {
+		config := newTestConfig()
+		config.Proxy = proxy
+		if _, err := NewTracingClient(config); err == nil {
+			t.Errorf("%s: expected error", proxy)
+		}
+	}
+	config := newTestConfig()
+	config.Proxy = "http://proxy:3128"
+	config.HTTP3 = true
+	if _, err := NewTracingClient(config); err == nil {
+		t.Errorf("Expected error for proxy in HTTP/3 mode")
+	}
+}
+
+func TestIsProxyError(t *testing.T) {
+	if IsProxyError(nil) || IsProxyError(errors.New("some error")) {
+		t.Errorf("Expected no proxy error")
+	}
+}
@@ -0,0 +1,98 @@
+package tracing
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// RequestTrace records the phase timings of a single request.
+// The hooks can be called by the transport even after the response was received,
+// e.g. by a connection attempt which lost the race against an idle connection.
+type RequestTrace struct {
+	mutex sync.Mutex
+
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	wroteRequest time.Time
+	firstByte    time.Time
+
+	reused bool
+}
+
+// WithRequestTrace returns a shallow copy of the request which records its phase timings in the returned trace
+func WithRequestTrace(req *http.Request) (*http.Request, *RequestTrace) {
+	rt := &RequestTrace{}
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { rt.setNow(&rt.dnsStart) },
+		DNSDone:  func(httptrace.DNSDoneInfo) { rt.setNow(&rt.dnsDone) },
+		ConnectStart: func(string, string) {
+			// with several addresses only the first connection attempt counts
+			rt.mutex.Lock()
+			if rt.connectStart.IsZero() {
+				rt.connectStart = time.Now()
+			}
+			rt.mutex.Unlock()
+		},
+		ConnectDone:       func(string, string, error) { rt.setNow(&rt.connectDone) },
+		TLSHandshakeStart: func() { rt.setNow(&rt.tlsStart) },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { rt.setNow(&rt.tlsDone) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			rt.mutex.Lock()
+			rt.reused = info.Reused
+			rt.mutex.Unlock()
+		},
+		WroteRequest:         func(httptrace.WroteRequestInfo) { rt.setNow(&rt.wroteRequest) },
+		GotFirstResponseByte: func() { rt.setNow(&rt.firstByte) },
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace)), rt
+}
+
+func (rt *RequestTrace) setNow(t *time.Time) {
+	now := time.Now()
+	rt.mutex.Lock()
+	*t = now
+	rt.mutex.Unlock()
+}
+
+func (rt *RequestTrace) phase(start *time.Time, end *time.Time) time.Duration {
+	rt.mutex.Lock()
+	defer rt.mutex.Unlock()
+	if start.IsZero() || end.IsZero() || end.Before(*start) {
+		return 0
+	}
+	return end.Sub(*start)
+}
+
+// Reused returns true if the request was sent over an already open connection
+func (rt *RequestTrace) Reused() bool {
+	rt.mutex.Lock()
+	defer rt.mutex.Unlock()
+	return rt.reused
+}
+
+// DNS returns the duration of the DNS lookup
+func (rt *RequestTrace) DNS() time.Duration {
+	return rt.phase(&rt.dnsStart, &rt.dnsDone)
+}
+
+// Connect returns the duration of the TCP connect
+func (rt *RequestTrace) Connect() time.Duration {
+	return rt.phase(&rt.connectStart, &rt.connectDone)
+}
+
+// TLS returns the duration of the TLS handshake
+func (rt *RequestTrace) TLS() time.Duration {
+	return rt.phase(&rt.tlsStart, &rt.tlsDone)
+}
+
+// TimeToFirstByte returns the time between sending the request and receiving the first byte of the response
+func (rt *RequestTrace) TimeToFirstByte() time.Duration {
+	return rt.phase(&rt.wroteRequest, &rt.firstByte)
+}
@@ -0,0 +1,47 @@
+package tracing
+
+import (
+	"io"
+	"net/http"
+	"testing"
+)
+
+func tracedRequest(t *testing.T, client *Client, url string) *RequestTrace {
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	req, trace := WithRequestTrace(req)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	_, _ = io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	return trace
+}
+
+func TestRequestTrace(t *testing.T) {
+	url := startHTTPServer(t)
+	client := newTestClient(t, newTestConfig())
+	defer client.Close()
+
+	trace := tracedRequest(t, client, url)
+	if trace.Reused() {
+		t.Errorf("Expected new connection for first request")
+	}
+	if trace.Connect() <= 0 {
+		t.Errorf("Expected connect time for new connection, got %s", trace.Connect())
+	}
+	if trace.TimeToFirstByte() <= 0 {
+		t.Errorf("Expected time to first byte, got %s", trace.TimeToFirstByte())
+	}
+	if trace.TLS() != 0 || trace.DNS() != 0 {
+		t.Errorf("Expected no TLS and DNS phase for plain IP address, got %s and %s", trace.TLS(), trace.DNS())
+	}
+
+	trace = tracedRequest(t, client, url)
+	if !trace.Reused() {
+		t.Errorf("Expected reused connection for second request")
+	}
+	if trace.Connect() != 0 {
+		t.Errorf("Expected no connect time for reused connection, got %s", trace.Connect())
+	}
+}
@@ -0,0 +1,155 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type dnsCacheEntry struct {
+	addrs   []string
+	expires time.Time
+}
+
+// resolver maps host:port to the addresses to dial. Addresses come from the
+// curl style -resolve overrides or from a DNS cache with a fixed TTL.
+type resolver struct {
+	netResolver *net.Resolver
+	overrides   map[string][]string // host:port -> list of ip:port
+	ttl         time.Duration       // 0 disables the cache
+
+	mutex sync.Mutex
+	cache map[string]dnsCacheEntry // host -> list of ip
+
+	idx     uint64
+	lookups int64
+	hits    int64
+}
+
+// parseResolveOverride parses an override in the form host:port:addr[,addr]...
+// IPv6 addresses have to be enclosed in brackets.
+func parseResolveOverride(override string) (string, []string, error) {
+	parts := strings.SplitN(override, ":", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return "", nil, errors.New("invalid resolve override, expected host:port:addr: " + override)
+	}
+	var addrs []string
+	for _, addr := range strings.Split(parts[2], ",") {
+		addr = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(addr), "["), "]")
+		if net.ParseIP(addr) == nil {
+			return "", nil, errors.New("invalid address in resolve override: " + override)
+		}
+		addrs = append(addrs, net.JoinHostPort(addr, parts[1]))
+	}
+	return net.JoinHostPort(parts[0], parts[1]), addrs, nil
+}
+
+func newResolver(netResolver *net.Resolver, overrides []string, ttl time.Duration) (*resolver, error) {
+	r := &resolver{
+		netResolver: netResolver,
+		overrides:   make(map[string][]string),
+		ttl:         ttl,
+		cache:       make(map[string]dnsCacheEntry),
+	}
+	for _, override := range overrides {
+		hostPort, addrs, err := parseResolveOverride(override)
+		if err != nil {
+			return nil, err
+		}
+		// several overrides for the same host:port add up
+		r.overrides[hostPort] = append(r.overrides[hostPort], addrs...)
+	}
+	return r, nil
+}
+
+// resolve returns the addresses to dial for host:port in round-robin order.
+// Without an override or cache the address is returned unchanged.
+func (r *resolver) resolve(ctx context.Context, address string) ([]string, error) {
+	if addrs, ok := r.overrides[address]; ok {
+		return r.rotate(addrs), nil
+	}
+	if r.ttl <= 0 {
+		return []string{address}, nil
+	}
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	if net.ParseIP(host) != nil {
+		return []string{address}, nil
+	}
+	ips, err := r.lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	addrs := make([]string, len(ips))
+	for i, ip := range ips {
+		addrs[i] = net.JoinHostPort(ip, port)
+	}
+	return r.rotate(addrs), nil
+}
+
+// lookup resolves the host via the DNS cache
+func (r *resolver) lookup(ctx context.Context, host string) ([]string, error) {
+	now := time.Now()
+	r.mutex.Lock()
+	entry, ok := r.cache[host]
+	r.mutex.Unlock()
+	if ok && now.Before(entry.expires) {
+		atomic.AddInt64(&r.hits, 1)
+		return entry.addrs, nil
+	}
+
+	atomic.AddInt64(&r.lookups, 1)
+	ips, err := r.netResolver.LookupHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	r.mutex.Lock()
+	r.cache[host] = dnsCacheEntry{addrs: ips, expires: now.Add(r.ttl)}
+	r.mutex.Unlock()
+	return ips, nil
+}
+
+func (r *resolver) rotate(addrs []string) []string {
+	if len(addrs) <= 1 {
+		return addrs
+	}
+	start := int(atomic.AddUint64(&r.idx, 1) % uint64(len(addrs)))
+	rotated := make([]string, 0, len(addrs))
+	rotated = append(rotated, addrs[start:]...)
+	return append(rotated, addrs[:start]...)
+}
+
+// dialResolved tries the resolved addresses in turn until one of them succeeds
+func (t *Client) dialResolved(ctx context.Context, address string, dial func(address string) (net.Conn, error)) (net.Conn, error) {
+	addrs, err := t.resolver.resolve(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	for _, addr := range addrs {
+		var conn net.Conn
+		conn, err = dial(addr)
+		if err == nil {
+			return conn, nil
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, err
+}
+
+// DNSStats returns the number of DNS lookups and DNS cache hits
+func (t *Client) DNSStats() (lookups int64, hits int64) {
+	return atomic.LoadInt64(&t.resolver.lookups), atomic.LoadInt64(&t.resolver.hits)
+}
+
+// IsDNSCacheEnabled returns true if DNS lookups are cached
+func (t *Client) IsDNSCacheEnabled() bool {
+	return t.resolver.ttl > 0
+}
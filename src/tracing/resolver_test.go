@@ -0,0 +1,111 @@
+package tracing
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestParseResolveOverride(t *testing.T) {
+	hostPort, addrs, err := parseResolveOverride("example.com:443:10.0.0.1,[::1]")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if hostPort != "example.com:443" {
+		t.Errorf("Expected example.com:443, got %s", hostPort)
+	}
+	if len(addrs) != 2 || addrs[0] != "10.0.0.1:443" || addrs[1] != "[::1]:443" {
+		t.Errorf("Unexpected addresses %v", addrs)
+	}
+
+	for _, override := range []string{"example.com", "example.com:443", "example.com:443:", ":443:10.0.0.1", "example.com:443:not-an-ip"} {
+		if _, _, err := parseResolveOverride(override); err == nil {
+			t.Errorf("%s: expected error", override)
+		}
+	}
+}
+
+func TestResolverRoundRobin(t *testing.T) {
+	r, err := newResolver(net.DefaultResolver, []string{"example.com:80:10.0.0.1", "example.com:80:10.0.0.2,10.0.0.3"}, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	first := make(map[string]int)
+	for i := 0; i < 6; i++ {
+		addrs, err := r.resolve(context.Background(), "example.com:80")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(addrs) != 3 {
+			t.Fatalf("Expected 3 addresses, got %v", addrs)
+		}
+		first[addrs[0]]++
+	}
+	for _, addr := range []string{"10.0.0.1:80", "10.0.0.2:80", "10.0.0.3:80"} {
+		if first[addr] != 2 {
+			t.Errorf("Expected %s to be tried first twice, got %d", addr, first[addr])
+		}
+	}
+
+	addrs, _ := r.resolve(context.Background(), "other.com:80")
+	if len(addrs) != 1 || addrs[0] != "other.com:80" {
+		t.Errorf("Expected address without override to be unchanged, got %v", addrs)
+	}
+}
+
+func TestResolveOverride(t *testing.T) {
+	server, _ := url.Parse(startHTTPServer(t))
+	config := newTestConfig()
+	config.Resolve = []string{"backend.invalid:" + server.Port() + ":127.0.0.1"}
+	client := newTestClient(t, config)
+	defer client.Close()
+
+	doRequests(t, client, "http://backend.invalid:"+server.Port()+"/", 1)
+}
+
+func TestDNSCache(t *testing.T) {
+	server, _ := url.Parse(startHTTPServer(t))
+	config := newTestConfig()
+	config.KeepAlive = false
+	config.DNSCacheTTL = time.Minute
+	client := newTestClient(t, config)
+	defer client.Close()
+
+	if !client.IsDNSCacheEnabled() {
+		t.Fatalf("Expected DNS cache to be enabled")
+	}
+	doRequests(t, client, "http://localhost:"+server.Port()+"/", 4)
+	if lookups, hits := client.DNSStats(); lookups != 1 || hits != 3 {
+		t.Errorf("Expected 1 lookup and 3 cache hits, got %d and %d", lookups, hits)
+	}
+}
+
+func TestDNSCacheExpires(t *testing.T) {
+	r, _ := newResolver(net.DefaultResolver, nil, time.Millisecond)
+	for i := 0; i < 2; i++ {
+		if _, err := r.resolve(context.Background(), "localhost:80"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if r.lookups != 2 || r.hits != 0 {
+		t.Errorf("Expected 2 lookups and no hits, got %d and %d", r.lookups, r.hits)
+	}
+
+	if _, err := r.resolve(context.Background(), "localhost"); err == nil {
+		t.Errorf("Expected error for address without port")
+	}
+	if _, err := r.resolve(context.Background(), "host.invalid:80"); err == nil {
+		t.Errorf("Expected error for unknown host")
+	}
+}
+
+func TestInvalidResolveOverride(t *testing.T) {
+	config := newTestConfig()
+	config.Resolve = []string{"example.com"}
+	if _, err := NewTracingClient(config); err == nil {
+		t.Errorf("Expected error for invalid override")
+	}
+}
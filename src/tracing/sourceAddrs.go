@@ -0,0 +1,123 @@
+package tracing
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"sync/atomic"
+)
+
+// SourceStats contains the connection counters of one local source address
+type SourceStats struct {
+	IP      net.IP
+	Current int32
+	Opened  int32
+}
+
+type sourceAddr struct {
+	ip                 net.IP
+	currentConnections int32
+	openedConnections  int32
+}
+
+// ParseSourceAddrs parses a comma separated list of IP addresses and interface names.
+// For an interface, all of its unicast addresses except link-local ones are used.
+func ParseSourceAddrs(list string) ([]net.IP, error) {
+	var ips []net.IP
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if ip := net.ParseIP(item); ip != nil {
+			ips = append(ips, ip)
+			continue
+		}
+		iface, err := net.InterfaceByName(item)
+		if err != nil {
+			return nil, errors.New("invalid source address or interface: " + item)
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			return nil, errors.New("failed to get addresses of interface " + item + ": " + err.Error())
+		}
+		found := false
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok || ipNet.IP.IsLinkLocalUnicast() {
+				continue
+			}
+			ips = append(ips, ipNet.IP)
+			found = true
+		}
+		if !found {
+			return nil, errors.New("interface " + item + " has no usable address")
+		}
+	}
+	return ips, nil
+}
+
+// nextSourceAddr returns the next local address in round-robin order or nil if no source addresses are configured.
+// For an IP address to dial only source addresses of the same family are used.
+func (t *Client) nextSourceAddr(address string) (*sourceAddr, error) {
+	if len(t.sourceAddrs) == 0 {
+		return nil, nil
+	}
+	var ip net.IP
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		ip = net.ParseIP(host)
+	}
+	matches := 0
+	for _, s := range t.sourceAddrs {
+		if sameFamily(s.ip, ip) {
+			matches++
+		}
+	}
+	if matches == 0 {
+		return nil, errors.New("no source address of the same family as " + address)
+	}
+	n := int(atomic.AddUint64(&t.sourceIdx, 1) % uint64(matches))
+	for _, s := range t.sourceAddrs {
+		if !sameFamily(s.ip, ip) {
+			continue
+		}
+		if n == 0 {
+			return s, nil
+		}
+		n--
+	}
+	return nil, nil
+}
+
+// sameFamily returns true if both addresses are IPv4 or both are IPv6. A nil target matches every source.
+func sameFamily(source net.IP, target net.IP) bool {
+	return target == nil || (source.To4() != nil) == (target.To4() != nil)
+}
+
+func (s *sourceAddr) connectionOpened() {
+	if s == nil {
+		return
+	}
+	atomic.AddInt32(&s.openedConnections, 1)
+	atomic.AddInt32(&s.currentConnections, 1)
+}
+
+func (s *sourceAddr) connectionClosed() {
+	if s == nil {
+		return
+	}
+	atomic.AddInt32(&s.currentConnections, -1)
+}
+
+// SourceStats returns the connection counters per source address
+func (t *Client) SourceStats() []SourceStats {
+	result := make([]SourceStats, len(t.sourceAddrs))
+	for i, s := range t.sourceAddrs {
+		result[i] = SourceStats{
+			IP:      s.ip,
+			Current: atomic.LoadInt32(&s.currentConnections),
+			Opened:  atomic.LoadInt32(&s.openedConnections),
+		}
+	}
+	return result
+}
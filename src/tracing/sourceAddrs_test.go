@@ -0,0 +1,131 @@
+package tracing
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestParseSourceAddrs(t *testing.T) {
+	ips, err := ParseSourceAddrs("10.0.0.5, 10.0.0.6,,::1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []string{"10.0.0.5", "10.0.0.6", "::1"}
+	if len(ips) != len(expected) {
+		t.Fatalf("Expected %d addresses, got %d", len(expected), len(ips))
+	}
+	for i, ip := range ips {
+		if ip.String() != expected[i] {
+			t.Errorf("Expected %s, got %s", expected[i], ip)
+		}
+	}
+
+	ips, err = ParseSourceAddrs("")
+	if err != nil || len(ips) != 0 {
+		t.Errorf("Expected no addresses for empty list, got %v, %v", ips, err)
+	}
+
+	if _, err = ParseSourceAddrs("no-such-interface0"); err == nil {
+		t.Errorf("Expected error for unknown interface")
+	}
+}
+
+func TestParseSourceAddrsInterface(t *testing.T) {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		t.Skipf("Cannot list interfaces: %v", err)
+	}
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagLoopback == 0 {
+			continue
+		}
+		ips, err := ParseSourceAddrs(iface.Name)
+		if err != nil {
+			t.Fatalf("Unexpected error for interface %s: %v", iface.Name, err)
+		}
+		for _, ip := range ips {
+			if !ip.IsLoopback() {
+				t.Errorf("Expected loopback address, got %s", ip)
+			}
+		}
+		return
+	}
+	t.Skip("No loopback interface found")
+}
+
+func TestSourceAddrRotation(t *testing.T) {
+	var mutex sync.Mutex
+	remotes := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, _ := net.SplitHostPort(r.RemoteAddr)
+		mutex.Lock()
+		remotes[host]++
+		mutex.Unlock()
+	}))
+	defer server.Close()
+
+	// binding to 127.0.0.2 works on Linux without further setup
+	probe, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.IPv4(127, 0, 0, 2)})
+	if err != nil {
+		t.Skipf("127.0.0.2 not available: %v", err)
+	}
+	_ = probe.Close()
+
+	config := newTestConfig()
+	config.KeepAlive = false
+	config.SourceAddrs = []net.IP{net.IPv4(127, 0, 0, 1), net.IPv4(127, 0, 0, 2)}
+	client := newTestClient(t, config)
+	defer client.Close()
+
+	doRequests(t, client, server.URL, 6)
+
+	if remotes["127.0.0.1"] != 3 || remotes["127.0.0.2"] != 3 {
+		t.Errorf("Expected 3 requests from each source address, got %v", remotes)
+	}
+	for _, source := range client.SourceStats() {
+		if source.Opened != 3 {
+			t.Errorf("Expected 3 connections from %s, got %d", source.IP, source.Opened)
+		}
+	}
+}
+
+func TestSourceAddrFamily(t *testing.T) {
+	config := newTestConfig()
+	config.SourceAddrs = []net.IP{net.IPv4(10, 0, 0, 5), net.ParseIP("fd00::5"), net.IPv4(10, 0, 0, 6)}
+	client := newTestClient(t, config)
+	defer client.Close()
+
+	tests := []struct {
+		address  string
+		expected []string
+	}{
+		{"192.0.2.1:80", []string{"10.0.0.5", "10.0.0.6"}},
+		{"[2001:db8::1]:80", []string{"fd00::5"}},
+		{"example.com:80", []string{"10.0.0.5", "fd00::5", "10.0.0.6"}},
+	}
+	for _, test := range tests {
+		seen := make(map[string]int)
+		for range 6 * len(test.expected) {
+			source, err := client.nextSourceAddr(test.address)
+			if err != nil {
+				t.Fatalf("Unexpected error for %s: %v", test.address, err)
+			}
+			seen[source.ip.String()]++
+		}
+		for _, ip := range test.expected {
+			if seen[ip] != 6 {
+				t.Errorf("Expected 6 connections from %s to %s, got %v", ip, test.address, seen)
+			}
+		}
+	}
+
+	config.SourceAddrs = []net.IP{net.IPv4(10, 0, 0, 5)}
+	client = newTestClient(t, config)
+	defer client.Close()
+	if _, err := client.nextSourceAddr("[2001:db8::1]:80"); err == nil {
+		t.Errorf("Expected error for IPv6 target without IPv6 source address")
+	}
+}
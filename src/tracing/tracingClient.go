@@ -3,28 +3,94 @@
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
+	"net/url"
+	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/quic-go/quic-go/http3"
 )
 
+// Config contains the options for the transport of the tracing client
+type Config struct {
+	Timeout time.Duration
+	HTTP3   bool
+
+	KeepAlive           bool          // reuse connections between requests
+	MaxConnsPerHost     int           // 0 means no limit
+	MaxIdleConnsPerHost int           // idle connections kept open per host
+	IdleConnTimeout     time.Duration // 0 means no limit
+	Connections         int           // if > 0, spread requests over a fixed number of connections per host
+
+	Proxy string // http://, https://, socks5:// proxy URL. If empty, the environment is used
+
+	SourceAddrs []net.IP // local addresses used in turn for new connections
+
+	Resolve     []string      // curl style host:port:addr[,addr] overrides
+	DNSCacheTTL time.Duration // 0 disables the DNS cache
+
+	UnixSocket string // if set, all connections are opened to this Unix domain socket
+}
+
 type Client struct {
 	transport          *http.Transport
+	http3Transport     *http3.Transport
 	dialer             net.Dialer
 	client             http.Client
 	CurrentConnections int32
 	openedConnections  int32
 	closedConnections  int32
+
+	// QUIC handshake metrics, only used in HTTP/3 mode
+	quicHandshakes    int64
+	quicHandshakeTime int64 // nanoseconds
+	quic0RTT          int64
+
+	proxyFunc        func(*url.URL) (*url.URL, error)
+	proxyAddrs       sync.Map
+	proxyConnections int64
+	proxyTunnels     int64
+
+	sourceAddrs []*sourceAddr
+	sourceIdx   uint64
+
+	resolver *resolver
+
+	unixSocket string
+
+	timeout atomic.Int64 // request timeout in nanoseconds, 0 means no limit
 }
 
-func NewTracingClient(timeout time.Duration) *Client {
+func NewTracingClient(config Config) (*Client, error) {
+	proxyFunc, err := newProxyFunc(config.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	if config.HTTP3 && config.Proxy != "" {
+		return nil, errors.New("proxies are not supported in HTTP/3 mode")
+	}
+	if config.HTTP3 && config.UnixSocket != "" {
+		return nil, errors.New("unix domain sockets are not supported in HTTP/3 mode")
+	}
+	if config.HTTP3 && config.Connections > 0 {
+		return nil, errors.New("a fixed number of connections is not supported in HTTP/3 mode")
+	}
+	if !config.KeepAlive && config.Connections > 0 {
+		return nil, errors.New("a fixed number of connections requires keep-alive")
+	}
+
 	transport := http.DefaultTransport.(*http.Transport).Clone()
 	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
 	transport.MaxIdleConns = 0 // No Limit
-	transport.MaxIdleConnsPerHost = 100
+	transport.MaxIdleConnsPerHost = config.MaxIdleConnsPerHost
+	transport.MaxConnsPerHost = config.MaxConnsPerHost
+	transport.IdleConnTimeout = config.IdleConnTimeout
+	transport.DisableKeepAlives = !config.KeepAlive
 
 	dial := net.Dialer{
 		Timeout:   30 * time.Second,
@@ -32,9 +98,14 @@ func NewTracingClient(timeout time.Duration) *Client {
 		Resolver:  net.DefaultResolver,
 	}
 
+	resolver, err := newResolver(dial.Resolver, config.Resolve, config.DNSCacheTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	// the timeout is applied per request in Do, so that it can be changed while the client is used
 	client := http.Client{
 		Transport: transport,
-		Timeout:   timeout,
 	}
 
 	tc := &Client{
@@ -44,11 +115,64 @@ func NewTracingClient(timeout time.Duration) *Client {
 		CurrentConnections: 0,
 		openedConnections:  0,
 		closedConnections:  0,
+		proxyFunc:          proxyFunc,
+		resolver:           resolver,
+		unixSocket:         config.UnixSocket,
+	}
+	tc.SetTimeout(config.Timeout)
+	for _, ip := range config.SourceAddrs {
+		tc.sourceAddrs = append(tc.sourceAddrs, &sourceAddr{ip: ip})
 	}
 
 	tc.transport.DialContext = tc.DialContext
+	tc.transport.Proxy = tc.proxy
+	tc.transport.OnProxyConnectResponse = tc.onProxyConnectResponse
 
-	return tc
+	if config.Connections > 0 {
+		tc.client.Transport = newConnectionPool(tc.transport, config.Connections)
+	}
+
+	if config.HTTP3 {
+		tc.http3Transport = tc.newHTTP3Transport()
+		tc.client.Transport = tc.http3Transport
+	}
+
+	return tc, nil
+}
+
+// IsHTTP3 returns true if requests are sent via QUIC
+func (t *Client) IsHTTP3() bool {
+	return t.http3Transport != nil
+}
+
+// SetTimeout changes the timeout of the following requests, including reading the body. 0 means no limit.
+func (t *Client) SetTimeout(timeout time.Duration) {
+	t.timeout.Store(int64(timeout))
+}
+
+// Timeout returns the current request timeout
+func (t *Client) Timeout() time.Duration {
+	return time.Duration(t.timeout.Load())
+}
+
+// Connections returns the number of currently open, opened and closed connections
+func (t *Client) Connections() (current int32, opened int32, closed int32) {
+	return atomic.LoadInt32(&t.CurrentConnections),
+		atomic.LoadInt32(&t.openedConnections),
+		atomic.LoadInt32(&t.closedConnections)
+}
+
+// CloseIdleConnections closes all connections which are not in use
+func (t *Client) CloseIdleConnections() {
+	t.client.CloseIdleConnections()
+}
+
+// Close closes all connections of the client
+func (t *Client) Close() {
+	t.CloseIdleConnections()
+	if t.http3Transport != nil {
+		_ = t.http3Transport.Close()
+	}
 }
 
 func (t *Client) String() {
@@ -57,6 +181,7 @@ func (t *Client) String() {
 	fmt.Println("Current Connections:", t.CurrentConnections)
 	fmt.Println("Opened Connections:", t.openedConnections)
 	fmt.Println("Closed Connections:", t.closedConnections)
+	fmt.Println("HTTP3:", t.IsHTTP3())
 	fmt.Println("Force Attempt HTTP2:", t.transport.ForceAttemptHTTP2)
 	fmt.Println("Max Idle Connections:", t.transport.MaxIdleConns)
 	fmt.Println("Max Idle Connections Per Host:", t.transport.MaxIdleConnsPerHost)
@@ -81,28 +206,73 @@ func (t *Client) String() {
 	fmt.Println("Dialer Fallback Local Address:", t.dialer.LocalAddr)
 	fmt.Println("Resolver Strict Errors:", t.dialer.Resolver.StrictErrors)
 	fmt.Println("Resolver Prefer Go:", t.dialer.Resolver.PreferGo)
-	fmt.Println("Client Timeout:", t.client.Timeout)
+	fmt.Println("Client Timeout:", t.Timeout())
 	fmt.Println("Client Cookie Jar:", t.client.Jar)
 }
 
 func (t *Client) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
-	conn, err := t.dialer.DialContext(ctx, network, address)
+	var conn net.Conn
+	var err error
+	var source *sourceAddr
+	if path, ok := t.unixSocketPath(address); ok {
+		conn, err = t.dialer.DialContext(ctx, "unix", path)
+	} else {
+		conn, err = t.dialResolved(ctx, address, func(address string) (net.Conn, error) {
+			dialer := t.dialer
+			var err error
+			source, err = t.nextSourceAddr(address)
+			if err != nil {
+				return nil, err
+			}
+			if source != nil {
+				dialer.LocalAddr = &net.TCPAddr{IP: source.ip}
+			}
+			return dialer.DialContext(ctx, network, address)
+		})
+	}
 	c := &Connection{Conn: conn}
 	c.OnEventCallback = func(clientClosed bool, serverClosed bool, err error) {
 		atomic.AddInt32(&t.closedConnections, 1)
 		atomic.AddInt32(&t.CurrentConnections, -1)
+		source.connectionClosed()
 	}
 
 	if err == nil {
 		atomic.AddInt32(&t.openedConnections, 1)
 		atomic.AddInt32(&t.CurrentConnections, 1)
+		source.connectionOpened()
+		if t.isProxyAddr(address) {
+			atomic.AddInt64(&t.proxyConnections, 1)
+		}
 	}
 	return c, err
 }
 
 func (t *Client) Do(req *http.Request) (resp *http.Response, err error) {
-	resp, err = t.client.Do(req)
-	return
+	timeout := t.Timeout()
+	if timeout <= 0 {
+		return t.client.Do(req)
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	resp, err = t.client.Do(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelBody releases the timeout of the request when the body is closed
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
 }
 
 func call(tracingClient *Client) {
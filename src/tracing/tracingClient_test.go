@@ -0,0 +1,137 @@
+package tracing
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func newTestConfig() Config {
+	return Config{
+		Timeout:             5 * time.Second,
+		KeepAlive:           true,
+		MaxIdleConnsPerHost: 100,
+		IdleConnTimeout:     90 * time.Second,
+	}
+}
+
+func newTestClient(t *testing.T, config Config) *Client {
+	client, err := NewTracingClient(config)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	return client
+}
+
+func startHTTPServer(t *testing.T) string {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func doRequests(t *testing.T, client *Client, url string, n int) {
+	for i := 0; i < n; i++ {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		_, _ = io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+	}
+}
+
+func TestKeepAliveReusesConnection(t *testing.T) {
+	url := startHTTPServer(t)
+	client := newTestClient(t, newTestConfig())
+	defer client.Close()
+
+	doRequests(t, client, url, 5)
+	if _, opened, _ := client.Connections(); opened != 1 {
+		t.Errorf("Expected 1 opened connection, got %d", opened)
+	}
+}
+
+func TestNoKeepAliveOpensConnectionPerRequest(t *testing.T) {
+	url := startHTTPServer(t)
+	config := newTestConfig()
+	config.KeepAlive = false
+	client := newTestClient(t, config)
+	defer client.Close()
+
+	doRequests(t, client, url, 5)
+	if _, opened, _ := client.Connections(); opened != 5 {
+		t.Errorf("Expected 5 opened connections, got %d", opened)
+	}
+	waitFor(t, "connections to close", func() bool {
+		current, _, closed := client.Connections()
+		return current == 0 && closed == 5
+	})
+}
+
+func TestFixedConnectionPool(t *testing.T) {
+	url := startHTTPServer(t)
+	config := newTestConfig()
+	config.Connections = 3
+	client := newTestClient(t, config)
+	defer client.Close()
+
+	doRequests(t, client, url, 12)
+	if current, opened, _ := client.Connections(); opened != 3 || current != 3 {
+		t.Errorf("Expected 3 connections, got %d opened, %d open", opened, current)
+	}
+
+	client.CloseIdleConnections()
+	waitFor(t, "connections to close", func() bool {
+		current, _, _ := client.Connections()
+		return current == 0
+	})
+}
+
+func TestFixedConnectionPoolWithoutKeepAlive(t *testing.T) {
+	config := newTestConfig()
+	config.Connections = 3
+	config.KeepAlive = false
+	if _, err := NewTracingClient(config); err == nil {
+		t.Errorf("Expected error for a fixed number of connections without keep-alive")
+	}
+}
+
+func TestSetTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		_, _ = io.WriteString(w, "ok")
+	}))
+	t.Cleanup(server.Close)
+	client := newTestClient(t, newTestConfig())
+	defer client.Close()
+
+	client.SetTimeout(50 * time.Millisecond)
+	if client.Timeout() != 50*time.Millisecond {
+		t.Errorf("Expected timeout 50ms, got %s", client.Timeout())
+	}
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	if _, err := client.Do(req); err == nil || !os.IsTimeout(err) {
+		t.Errorf("Expected a timeout error, got %v", err)
+	}
+
+	client.SetTimeout(time.Second)
+	req, _ = http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil || string(body) != "ok" {
+		t.Errorf("Expected body ok, got %q, %v", body, err)
+	}
+}
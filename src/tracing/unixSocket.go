@@ -0,0 +1,52 @@
+package tracing
+
+import (
+	"encoding/hex"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Requests to Unix domain sockets get a pseudo host which contains the hex encoded socket path.
+// This keeps the connections of different sockets apart in the connection pool.
+const unixSocketHostSuffix = ".unix"
+
+// PrepareUnixSocketRequest rewrites a request with a URL like http+unix:///var/run/app.sock:/health
+// into a http request to the socket. The part after the first colon of the path is the request path.
+func PrepareUnixSocketRequest(req *http.Request) {
+	scheme, isUnix := strings.CutSuffix(req.URL.Scheme, "+unix")
+	if !isUnix {
+		return
+	}
+	socket, path, found := strings.Cut(req.URL.Path, ":")
+	if !found || path == "" {
+		path = "/"
+	}
+	req.URL.Scheme = scheme
+	req.URL.Host = hex.EncodeToString([]byte(socket)) + unixSocketHostSuffix
+	req.URL.Path = path
+	req.URL.RawPath = ""
+	if req.Host == "" {
+		req.Host = "localhost"
+	}
+}
+
+// unixSocketPath returns the socket path if the address belongs to a Unix domain socket
+func (t *Client) unixSocketPath(address string) (string, bool) {
+	if t.unixSocket != "" {
+		return t.unixSocket, true
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return "", false
+	}
+	encoded, found := strings.CutSuffix(host, unixSocketHostSuffix)
+	if !found {
+		return "", false
+	}
+	path, err := hex.DecodeString(encoded)
+	if err != nil {
+		return "", false
+	}
+	return string(path), true
+}
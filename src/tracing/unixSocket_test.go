@@ -0,0 +1,81 @@
+package tracing
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+)
+
+// startUnixSocketServer starts a server on a Unix domain socket which returns the request path
+func startUnixSocketServer(t *testing.T) string {
+	socket := filepath.Join(t.TempDir(), "app.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skipf("Unix domain sockets not available: %v", err)
+	}
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.Host+r.URL.Path)
+	})}
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(func() {
+		_ = server.Close()
+	})
+	return socket
+}
+
+func getBody(t *testing.T, client *Client, req *http.Request) string {
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	return string(body)
+}
+
+func TestUnixSocketURL(t *testing.T) {
+	t.Setenv("HTTP_PROXY", "http://127.0.0.1:1")
+	socket := startUnixSocketServer(t)
+	client := newTestClient(t, newTestConfig())
+	defer client.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, "http+unix://"+socket+":/health", nil)
+	PrepareUnixSocketRequest(req)
+	if body := getBody(t, client, req); body != "localhost/health" {
+		t.Errorf("Expected localhost/health, got %q", body)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, "http+unix://"+socket, nil)
+	PrepareUnixSocketRequest(req)
+	if body := getBody(t, client, req); body != "localhost/" {
+		t.Errorf("Expected localhost/, got %q", body)
+	}
+
+	if current, opened, _ := client.Connections(); current != 1 || opened != 1 {
+		t.Errorf("Expected 1 reused connection, got %d open, %d opened", current, opened)
+	}
+}
+
+func TestUnixSocketFlag(t *testing.T) {
+	config := newTestConfig()
+	config.UnixSocket = startUnixSocketServer(t)
+	client := newTestClient(t, config)
+	defer client.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, "http://app.internal/status", nil)
+	if body := getBody(t, client, req); body != "app.internal/status" {
+		t.Errorf("Expected app.internal/status, got %q", body)
+	}
+}
+
+func TestPrepareUnixSocketRequestIgnoresOtherSchemes(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/path", nil)
+	PrepareUnixSocketRequest(req)
+	if req.URL.String() != "http://example.com/path" {
+		t.Errorf("Expected URL to be unchanged, got %s", req.URL)
+	}
+}
@@ -2,7 +2,9 @@
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"github.com/s-macke/slapperx/src/metrics"
 	terminal "golang.org/x/term"
 	"log"
 	"math"
@@ -19,13 +21,6 @@
 	reservedHeightSpace = 3
 )
 
-// colors for histogram bars
-var colors = []string{
-	"\033[38;5;46m", "\033[38;5;47m", "\033[38;5;48m", "\033[38;5;49m", // green
-	"\033[38;5;149m", "\033[38;5;148m", "\033[38;5;179m", "\033[38;5;176m", // yellow
-	"\033[38;5;169m", "\033[38;5;168m", "\033[38;5;197m", "\033[38;5;196m", // red
-}
-
 // var partChar = []string{" ", "▏", "▎", "▍", "▌", "▋", "▊", "▉", "█"}
 
 type UI struct {
@@ -38,48 +33,100 @@ type UI struct {
 	plotWidth  int
 	plotHeight int
 
+	mu        sync.Mutex // guards the layout against a resize of the terminal
+	sizeError error      // the terminal has become too small for the histogram
+
 	wg   sync.WaitGroup
 	done chan bool
 
 	lbc *logBucketCalculator
+
+	series   *TimeSeries
+	view     view
+	showHelp bool      // show the keys instead of the view
+	help     []Binding // registered keys
+	prompt   *Prompt   // command line in the last line
+
+	filter statusFilter // responses shown in the histogram
+
+	autoFit     bool              // fit the latency range periodically to the observed latencies
+	fitInterval *metrics.Interval // requests finished since the last fit
+
+	thresholds *ThresholdMonitor
+	pusher     *metrics.Pusher
+	controller *Controller
 }
 
 // InitTerminal initializes the terminal and sets the UI dimensions
-func InitTerminal(minY time.Duration, maxY time.Duration) *UI {
+func InitTerminal(minY time.Duration, maxY time.Duration, scale scaleMode, edges []float64) *UI {
 	if !terminal.IsTerminal(int(os.Stdout.Fd())) {
 		panic("Not a terminal")
 	}
 	ui := UI{
-		start: time.Now(),
-		done:  make(chan bool),
+		start:  time.Now(),
+		done:   make(chan bool),
+		filter: noStatusFilter,
+	}
+	width, height, err := windowSize()
+	if err != nil {
+		log.Fatal(err)
 	}
-	ui.setWindowSize()
+	ui.setWindowSize(width, height)
 	ui.lbc = newLogBucketCalculator(minY, maxY, ui.plotHeight)
+	ui.lbc.customEdges = edges
+	ui.lbc.setScale(scale, ui.plotHeight)
+	if ui.lbc.scale != scale {
+		log.Fatal("not enough screen height for the bucket edges")
+	}
+	ui.fitInterval = stats.metrics.NewInterval()
+	ui.series = NewTimeSeries(stats.metrics.NewInterval())
 	return &ui
 }
 
+// Close stops all loops of the UI and waits for them
 func (ui *UI) Close() {
-	ui.done <- true
+	close(ui.done)
 	ui.wg.Wait()
 }
 
-func (ui *UI) setWindowSize() {
-	var err error
-	ui.terminalWidth, ui.terminalHeight, err = terminal.GetSize(int(os.Stdout.Fd()))
+// windowSize returns the size of the terminal and checks that the histogram fits
+func windowSize() (width int, height int, err error) {
+	width, height, err = terminal.GetSize(int(os.Stdout.Fd()))
 	if err != nil {
-		panic(err)
+		return 0, 0, err
+	}
+	if width < reservedWidthSpace {
+		return 0, 0, errors.New("not enough screen width, min 40 characters required")
+	}
+	if height-statsLines <= reservedHeightSpace {
+		return 0, 0, errors.New("not enough screen height, min 3 lines required")
 	}
+	return width, height, nil
+}
+
+func (ui *UI) setWindowSize(width int, height int) {
+	ui.terminalWidth = width
+	ui.terminalHeight = height
 
 	ui.plotWidth = ui.terminalWidth
 	ui.plotHeight = ui.terminalHeight - statsLines
+}
 
-	if ui.plotWidth < reservedWidthSpace {
-		log.Fatal("not enough screen width, min 40 characters required")
-	}
+// Resize recomputes the layout after the terminal has been resized. The histogram is migrated to the new
+// number of buckets. While the terminal is too small only a message is shown.
+func (ui *UI) Resize() {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
 
-	if ui.plotHeight <= reservedHeightSpace {
-		log.Fatal("not enough screen height, min 3 lines required")
+	width, height, err := windowSize()
+	ui.sizeError = err
+	if err != nil {
+		_, _ = fmt.Print("\033[H\033[2J", err)
+		return
 	}
+	ui.setWindowSize(width, height)
+	stats.timings.Rebucket(ui.lbc, func() { ui.lbc.updateBucket(ui.plotHeight) })
+	ui.clearScreen()
 }
 
 func (ui *UI) listParameters() {
@@ -105,68 +152,147 @@ func (ui *UI) printHistogramHeader(sb *strings.Builder, currentRate counter, cur
 	} else {
 		_, _ = fmt.Fprintf(sb, "\033[96mrate: %4d/%.1f RPS\033[0m ", currentRate.Load(), currentSetRate)
 	}
+	if ui.controller != nil && ui.controller.IsPaused() {
+		_, _ = fmt.Fprint(sb, "\033[31m[paused]\033[0m ")
+	}
+	_, _ = fmt.Fprintf(sb, "view: %s (? help) ", ui.view)
+	if ui.filter != noStatusFilter {
+		_, _ = fmt.Fprintf(sb, "%sfilter: %s\033[0m ", statusClassColors[ui.filter.class], ui.filter)
+	}
+	_, _ = fmt.Fprintf(sb, "y: %s ", ui.lbc)
+	if ui.autoFit {
+		_, _ = fmt.Fprint(sb, "[auto] ")
+	}
+	if total, failed := ui.thresholds.Status(); failed > 0 {
+		_, _ = fmt.Fprintf(sb, "\033[31mthresholds: %d/%d failed\033[0m ", failed, total)
+	} else if total > 0 {
+		_, _ = fmt.Fprintf(sb, "\033[32mthresholds: %d ok\033[0m ", total)
+	}
 
 	_, _ = fmt.Fprint(sb, "\r\nresponses: ")
 
+	// the colors of the status classes are the ones of the histogram bars
+	errorColor := statusClassColors[classError]
 	if stats.responses.ErrorNoSuchHost > 0 {
-		_, _ = fmt.Fprintf(sb, "\033[31m[No such host]: %-6d\033[0m ", stats.responses.ErrorNoSuchHost)
+		_, _ = fmt.Fprintf(sb, "%s[No such host]: %-6d\033[0m ", errorColor, stats.responses.ErrorNoSuchHost)
 	}
 	if stats.responses.ErrorConnRefused > 0 {
-		_, _ = fmt.Fprintf(sb, "\033[31m[Conn refused]: %-6d\033[0m ", stats.responses.ErrorConnRefused)
+		_, _ = fmt.Fprintf(sb, "%s[Conn refused]: %-6d\033[0m ", errorColor, stats.responses.ErrorConnRefused)
 	}
 	if stats.responses.ErrorEof > 0 {
-		_, _ = fmt.Fprintf(sb, "\033[31m[EOF]: %-6d\033[0m ", stats.responses.ErrorEof)
+		_, _ = fmt.Fprintf(sb, "%s[EOF]: %-6d\033[0m ", errorColor, stats.responses.ErrorEof)
+	}
+	if stats.responses.ErrorProxy > 0 {
+		_, _ = fmt.Fprintf(sb, "%s[Proxy error]: %-6d\033[0m ", errorColor, stats.responses.ErrorProxy)
 	}
 	if stats.responses.ErrorTimeout > 0 {
-		_, _ = fmt.Fprintf(sb, "\033[31m[Timeout]: %-6d\033[0m ", stats.responses.ErrorTimeout)
+		_, _ = fmt.Fprintf(sb, "%s[Timeout]: %-6d\033[0m ", errorColor, stats.responses.ErrorTimeout)
 	}
 	for status, counter := range stats.responses.status {
 		if c := counter.Load(); c > 0 {
-			if status >= 200 && status < 300 {
-				_, _ = fmt.Fprintf(sb, "\033[32m[%d]: %-6d\033[0m ", status, c)
-			} else {
-				_, _ = fmt.Fprintf(sb, "\033[31m[%d]: %-6d\033[0m ", status, c)
-			}
+			_, _ = fmt.Fprintf(sb, "%s[%d]: %-6d\033[0m ", statusClassColors[classOf(status)], status, c)
 		}
 	}
 }
 
+// printTransportInfo prints the connection, proxy and DNS counters, the connections per source address
+// and the QUIC handshake metrics when running in HTTP/3 mode. The line is cut at the terminal width.
+func (ui *UI) printTransportInfo(sb *strings.Builder) {
+	var line strings.Builder
+	_, _ = fmt.Fprintf(&line, "workers: %-4d timeout: %-6s ", trgt.Workers(), trgt.client.Timeout())
+	current, opened, _ := trgt.client.Connections()
+	_, _ = fmt.Fprintf(&line, "connections: %-5d opened: %-6d ", current, opened)
+	if proxyConnections, tunnels := trgt.client.ProxyStats(); proxyConnections > 0 {
+		_, _ = fmt.Fprintf(&line, "proxied: %-6d tunnels: %-6d ", proxyConnections, tunnels)
+	}
+	if trgt.client.IsHTTP3() {
+		handshakes, avgHandshake, zeroRTT := trgt.client.QUICStats()
+		_, _ = fmt.Fprintf(&line, "quic handshakes: %-5d ", handshakes)
+		_, _ = fmt.Fprintf(&line, "avg: %-9s ", avgHandshake.Round(100*time.Microsecond))
+		_, _ = fmt.Fprintf(&line, "0-RTT: %-5d ", zeroRTT)
+	}
+	if trgt.client.IsDNSCacheEnabled() {
+		lookups, hits := trgt.client.DNSStats()
+		_, _ = fmt.Fprintf(&line, "dns lookups: %-5d cache hits: %-6d ", lookups, hits)
+	}
+	for _, source := range trgt.client.SourceStats() {
+		_, _ = fmt.Fprintf(&line, "%s: %-5d ", source.IP, source.Current)
+	}
+	if ui.pusher != nil {
+		if failed, lastError := ui.pusher.Errors(); failed > 0 {
+			_, _ = fmt.Fprintf(&line, "push errors: %d (%s) ", failed, lastError)
+		}
+	}
+
+	text := []rune(line.String())
+	if len(text) > ui.terminalWidth-1 {
+		text = text[:ui.terminalWidth-1]
+	}
+	sb.WriteString(string(text))
+}
+
 // drawHistogram draws the histogram of response times
 func (ui *UI) drawHistogram(currentRate counter, currentSetRate float64) {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+	if ui.sizeError != nil {
+		return
+	}
+
 	var sb strings.Builder
 	sb.Grow(ui.terminalWidth*ui.terminalHeight*2 + ui.terminalHeight*(5*5+12*2)) // just a guess
 
-	colorMultiplier := float64(len(colors)) / float64(ui.lbc.buckets)
 	barWidth := int(ui.plotWidth) - reservedWidthSpace // reserve some space on right and left
 
-	tOk, tBad, max := stats.timings.prepareHistogramData()
+	totals, maximum := stats.timings.prepareHistogramData(ui.filter)
 
 	_, _ = fmt.Fprint(&sb, "\033[H") // clean screen
 	ui.printHistogramHeader(&sb, currentRate, currentSetRate)
-	_, _ = fmt.Fprint(&sb, "\r\n\r\n")
+	_, _ = fmt.Fprint(&sb, "\r\n")
+	ui.printTransportInfo(&sb)
+	_, _ = fmt.Fprint(&sb, "\r\n")
+	if ui.showHelp || ui.view != viewHistogram {
+		ui.drawView(&sb)
+		_, _ = fmt.Fprint(&sb, "\r\n")
+		ui.printPrompt(&sb)
+		_, _ = fmt.Print(sb.String())
+		return
+	}
 
-	width := float64(barWidth) / float64(max)
+	width := float64(barWidth) / float64(maximum)
 	for bkt := 0; bkt < ui.lbc.buckets; bkt++ {
 		label := ui.lbc.createLabel(bkt)
 
-		widthOk := int(float64(tOk[bkt]) * width)
-		widthBad := int(float64(tBad[bkt]) * width)
-		widthLeft := barWidth - widthOk - widthBad
-
-		_, _ = fmt.Fprintf(&sb, "%11s ms: [%s%6d%s/%s%6d%s] %s%s%s%s%s \r\n",
+		ok, bad := totals[class2xx][bkt], 0
+		for class := class3xx; class < statusClassCount; class++ {
+			bad += totals[class][bkt]
+		}
+		_, _ = fmt.Fprintf(&sb, "%11s ms: [%s%6d%s/%s%6d%s] ",
 			label,
 			"\033[32m",
-			tOk[bkt],
+			ok,
 			"\033[0m",
 			"\033[31m",
-			tBad[bkt],
-			"\033[0m",
-			colors[int(float64(bkt)*colorMultiplier)],
-			bytes.Repeat([]byte("E"), widthBad),
-			bytes.Repeat([]byte("█"), widthOk),
-			bytes.Repeat([]byte(" "), widthLeft),
+			bad,
 			"\033[0m")
+
+		// the bar is stacked by status class, failed requests are drawn with E
+		widthLeft := barWidth
+		for class := range statusClassCount {
+			n := int(float64(totals[class][bkt]) * width)
+			if n == 0 {
+				continue
+			}
+			char := []byte("█")
+			if class == classError {
+				char = []byte("E")
+			}
+			_, _ = fmt.Fprintf(&sb, "%s%s", statusClassColors[class], bytes.Repeat(char, n))
+			widthLeft -= n
+		}
+		_, _ = fmt.Fprintf(&sb, "\033[0m%s \r\n", bytes.Repeat([]byte(" "), max(widthLeft, 0)))
 	} // end for
+	ui.printPrompt(&sb)
 
 	_, _ = fmt.Print(sb.String())
 }
@@ -184,6 +310,13 @@ func (ui *UI) clearScreen() {
 func (ui *UI) Show() {
 	ui.clearScreen()
 
+	ui.wg.Add(1)
+	go func() {
+		defer ui.wg.Done()
+		ui.series.collect(ui.done)
+	}()
+	ui.startAutoFit()
+
 	var currentRate counter
 	go func() {
 		var lastSent int64
@@ -195,13 +328,16 @@ func (ui *UI) Show() {
 	}()
 
 	ticker := time.Tick(screenRefreshInterval)
+	ui.wg.Add(1)
 	go func() {
-		ui.wg.Add(1)
 		for {
 			select {
-			case <-ticker:
+			case now := <-ticker:
 				//trgt.client.String()
-				ui.drawHistogram(currentRate, stats.currentSetRate)
+				ui.drawHistogram(currentRate, stats.getSetRate())
+				if ui.pusher != nil {
+					ui.pusher.Tick(now)
+				}
 			case <-ui.done:
 				ui.wg.Done()
 				return
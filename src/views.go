@@ -0,0 +1,225 @@
+package slapperx
+
+import (
+	"fmt"
+	"github.com/s-macke/slapperx/src/report"
+	"strings"
+	"time"
+)
+
+// view is the panel shown below the header
+type view int
+
+const (
+	viewHistogram   view = iota // latency histogram of the last seconds
+	viewTimeSeries              // latency and rate of the whole run
+	viewHeatmap                 // requests per latency bucket and second
+	viewEndpoints               // table of the request names
+	viewErrors                  // recent error messages
+	viewConnections             // counters of the HTTP client
+	viewCount
+)
+
+var viewNames = []string{"histogram", "time series", "heatmap", "endpoints", "errors", "connections"}
+
+func (v view) String() string {
+	return viewNames[v]
+}
+
+// SetView shows the given view
+func (ui *UI) SetView(v view) {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+	ui.view = v
+	ui.showHelp = false
+	ui.clearScreen()
+}
+
+// NextView switches to the next view
+func (ui *UI) NextView() {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+	ui.view = (ui.view + 1) % viewCount
+	ui.showHelp = false
+	ui.clearScreen()
+}
+
+// ToggleView switches between the histogram and the given view
+func (ui *UI) ToggleView(v view) {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+	if ui.view == v {
+		ui.view = viewHistogram
+	} else {
+		ui.view = v
+	}
+	ui.showHelp = false
+	ui.clearScreen()
+}
+
+// ToggleHelp shows or hides the keys
+func (ui *UI) ToggleHelp() {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+	ui.showHelp = !ui.showHelp
+	ui.clearScreen()
+}
+
+// SetHelp sets the keys shown in the help
+func (ui *UI) SetHelp(bindings []Binding) {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+	ui.help = bindings
+}
+
+// SetPrompt sets the command prompt shown in the last line
+func (ui *UI) SetPrompt(prompt *Prompt) {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+	ui.prompt = prompt
+}
+
+// printPrompt prints the command line or the result of the last command in the last line
+func (ui *UI) printPrompt(sb *strings.Builder) {
+	if ui.prompt == nil {
+		return
+	}
+	text, failed := ui.prompt.Status()
+	text = string([]rune(text)[:min(len([]rune(text)), ui.terminalWidth-1)])
+	if failed {
+		_, _ = fmt.Fprintf(sb, "\033[31m%s\033[0m\033[K", text)
+	} else {
+		_, _ = fmt.Fprintf(sb, "%s\033[K", text)
+	}
+}
+
+// drawView draws the help or the current view other than the histogram below the header
+func (ui *UI) drawView(sb *strings.Builder) {
+	if ui.showHelp {
+		ui.writeLines(sb, ui.helpLines())
+		return
+	}
+	switch ui.view {
+	case viewTimeSeries:
+		ui.drawTimeSeries(sb)
+	case viewHeatmap:
+		ui.drawHeatmap(sb)
+	case viewEndpoints:
+		ui.writeLines(sb, ui.endpointLines())
+	case viewErrors:
+		ui.writeLines(sb, errorLines())
+	case viewConnections:
+		ui.writeLines(sb, connectionLines())
+	}
+}
+
+// writeLines writes exactly plotHeight lines cut at the terminal width. The lines must not contain colors.
+func (ui *UI) writeLines(sb *strings.Builder, lines []string) {
+	for i := 0; i < ui.plotHeight; i++ {
+		if i < len(lines) {
+			text := []rune(lines[i])
+			if len(text) > ui.terminalWidth-1 {
+				text = text[:ui.terminalWidth-1]
+			}
+			sb.WriteString(string(text))
+		}
+		sb.WriteString("\033[K")
+		if i < ui.plotHeight-1 {
+			sb.WriteString("\r\n")
+		}
+	}
+}
+
+// truncate cuts the text to n characters
+func truncate(text string, n int) string {
+	runes := []rune(text)
+	if len(runes) <= n {
+		return text
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+func (ui *UI) helpLines() []string {
+	lines := []string{"Keys:", ""}
+	for _, b := range ui.help {
+		lines = append(lines, fmt.Sprintf("  %-8s %s", b.Key, b.Description))
+	}
+	return lines
+}
+
+// endpointLines lists the requests of the HTTP file with their state, the rate of the last second and
+// the totals since the start or the last reset. Requests with the same name share the statistics.
+func (ui *UI) endpointLines() []string {
+	summaries := make(map[string]report.EndpointSummary)
+	for _, e := range stats.summary.Summary().Endpoints {
+		summaries[e.Name] = e
+	}
+	lines := []string{
+		fmt.Sprintf("%3s %-3s %-30s %-16s %9s %10s %8s %10s %10s", "#", "on", "name", "tags", "rate/s", "requests", "errors", "p50 ms", "p99 ms"),
+	}
+	for _, r := range trgt.requests.List() {
+		enabled := "off"
+		if r.Enabled {
+			enabled = "on"
+		}
+		e := summaries[r.Name]
+		lines = append(lines, fmt.Sprintf("%3d %-3s %-30s %-16s %9.1f %10d %7.2f%% %10.2f %10.2f",
+			r.Number, enabled, truncate(r.Name, 30), truncate(strings.Join(r.Tags, ","), 16),
+			ui.series.endpointRate(r.Name), e.Requests, 100*e.ErrorRate, e.Latency.P50Ms, e.Latency.P99Ms))
+	}
+	lines = append(lines, "", "e or :toggle <number|name|@tag|all> enables or disables requests")
+	if tags := trgt.requests.Tags(); len(tags) > 0 {
+		lines = append(lines, "tags: @"+strings.Join(tags, " @"))
+	}
+	return lines
+}
+
+// errorLines lists the failed requests and error responses, the most recent first
+func errorLines() []string {
+	messages := stats.errors.Recent()
+	if len(messages) == 0 {
+		return []string{"no errors"}
+	}
+	lines := []string{fmt.Sprintf("%-8s %8s  %-20s %s", "last", "count", "name", "message")}
+	now := time.Now()
+	for _, m := range messages {
+		lines = append(lines, fmt.Sprintf("%7.0fs %8d  %-20s %s", now.Sub(m.Last).Seconds(), m.Count, truncate(m.Name, 20), m.Message))
+	}
+	return lines
+}
+
+// connectionLines lists the counters of the HTTP client
+func connectionLines() []string {
+	current, opened, closed := trgt.client.Connections()
+	lines := []string{
+		fmt.Sprintf("connections open:   %d", current),
+		fmt.Sprintf("opened:             %d", opened),
+		fmt.Sprintf("closed:             %d", closed),
+		fmt.Sprintf("in-flight requests: %d", stats.getInFlightRequests()),
+	}
+	if proxyConnections, tunnels := trgt.client.ProxyStats(); proxyConnections > 0 {
+		lines = append(lines,
+			fmt.Sprintf("proxy connections:  %d", proxyConnections),
+			fmt.Sprintf("proxy tunnels:      %d", tunnels))
+	}
+	if trgt.client.IsDNSCacheEnabled() {
+		lookups, hits := trgt.client.DNSStats()
+		lines = append(lines,
+			fmt.Sprintf("dns lookups:        %d", lookups),
+			fmt.Sprintf("dns cache hits:     %d", hits))
+	}
+	if trgt.client.IsHTTP3() {
+		handshakes, avgHandshake, zeroRTT := trgt.client.QUICStats()
+		lines = append(lines,
+			fmt.Sprintf("quic handshakes:    %d", handshakes),
+			fmt.Sprintf("avg handshake:      %s", avgHandshake.Round(100*time.Microsecond)),
+			fmt.Sprintf("0-RTT resumptions:  %d", zeroRTT))
+	}
+	if sources := trgt.client.SourceStats(); len(sources) > 0 {
+		lines = append(lines, "", fmt.Sprintf("%-40s %8s %10s", "source address", "open", "opened"))
+		for _, source := range sources {
+			lines = append(lines, fmt.Sprintf("%-40s %8d %10d", source.IP, source.Current, source.Opened))
+		}
+	}
+	return lines
+}
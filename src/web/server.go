@@ -0,0 +1,156 @@
+package web
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/s-macke/slapperx/src/control"
+	"github.com/s-macke/slapperx/src/report"
+)
+
+//go:embed index.html
+var indexHTML []byte
+
+// maxHistory is the number of samples sent to a browser when it connects
+const maxHistory = 600
+
+// Controls are the operations offered in the web UI, the same as the load routes of the control API
+type Controls interface {
+	control.LoadControls
+}
+
+// Sample is the state of the run, published every second
+type Sample struct {
+	Time          int64                    `json:"time"` // unix milliseconds
+	Rate          float64                  `json:"rate"` // finished requests per second
+	SetRate       float64                  `json:"set_rate"`
+	Paused        bool                     `json:"paused"`
+	InFlight      int64                    `json:"in_flight"`
+	Requests      int64                    `json:"requests"` // finished in the last second
+	Errors        int64                    `json:"errors"`
+	Latency       report.LatencySummary    `json:"latency"`
+	TotalRequests int64                    `json:"total_requests"` // since the start or the last reset
+	TotalErrors   int64                    `json:"total_errors"`
+	Histogram     []report.HistogramBucket `json:"histogram,omitempty"` // since the start or the last reset
+}
+
+// Server serves the web UI and streams the samples to the browsers with server-sent events
+type Server struct {
+	controls Controls
+
+	mu      sync.Mutex
+	history []Sample
+	clients map[chan Sample]struct{}
+
+	done chan struct{} // closed when the server stops
+}
+
+func NewServer(controls Controls) *Server {
+	return &Server{
+		controls: controls,
+		clients:  make(map[chan Sample]struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Done returns a channel which is closed when the server stops
+func (s *Server) Done() <-chan struct{} {
+	return s.done
+}
+
+// Publish sends the sample to all browsers. Browsers which are too slow miss samples.
+func (s *Server) Publish(sample Sample) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for client := range s.clients {
+		select {
+		case client <- sample:
+		default:
+		}
+	}
+	// the histogram is only needed in the latest sample
+	sample.Histogram = nil
+	s.history = append(s.history, sample)
+	if len(s.history) > maxHistory {
+		s.history = s.history[len(s.history)-maxHistory:]
+	}
+}
+
+func (s *Server) subscribe() (chan Sample, []Sample) {
+	client := make(chan Sample, 10)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clients[client] = struct{}{}
+	return client, append([]Sample(nil), s.history...)
+}
+
+func (s *Server) unsubscribe(client chan Sample) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.clients, client)
+}
+
+// Handler returns the routes of the web UI
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(indexHTML)
+	})
+	mux.HandleFunc("GET /events", s.events)
+	control.HandleLoad(mux, s.controls)
+	return mux
+}
+
+// Serve serves the web UI in the background until the listener is closed
+func (s *Server) Serve(listener net.Listener) {
+	go func() {
+		defer close(s.done)
+		_ = http.Serve(listener, s.Handler())
+	}()
+}
+
+func writeEvent(w http.ResponseWriter, sample *Sample) error {
+	data, err := json.Marshal(sample)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "data: %s\n\n", data)
+	return err
+}
+
+// events streams the history and then every new sample
+func (s *Server) events(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	client, history := s.subscribe()
+	defer s.unsubscribe(client)
+	for i := range history {
+		if err := writeEvent(w, &history[i]); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	for {
+		select {
+		case sample := <-client:
+			if err := writeEvent(w, &sample); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package web
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/s-macke/slapperx/src/control"
+)
+
+type testControls struct {
+	mu      sync.Mutex
+	rate    float64
+	paused  bool
+	resets  int
+	changes []float64
+}
+
+func (c *testControls) Status() control.Status {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return control.Status{SetRate: c.rate, Paused: c.paused}
+}
+func (c *testControls) SetRate(rate float64) error {
+	if rate <= 0 {
+		return errors.New("invalid rate")
+	}
+	c.mu.Lock()
+	c.rate = rate
+	c.mu.Unlock()
+	return nil
+}
+func (c *testControls) ChangeRate(delta float64) error {
+	c.mu.Lock()
+	c.changes = append(c.changes, delta)
+	c.mu.Unlock()
+	return nil
+}
+func (c *testControls) Pause()  { c.mu.Lock(); c.paused = true; c.mu.Unlock() }
+func (c *testControls) Resume() { c.mu.Lock(); c.paused = false; c.mu.Unlock() }
+func (c *testControls) Reset()  { c.mu.Lock(); c.resets++; c.mu.Unlock() }
+
+func TestIndex(t *testing.T) {
+	server := httptest.NewServer(NewServer(&testControls{}).Handler())
+	defer server.Close()
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to get index: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		t.Errorf("Unexpected response %s %s", resp.Status, resp.Header.Get("Content-Type"))
+	}
+}
+
+func TestControls(t *testing.T) {
+	controls := &testControls{}
+	server := httptest.NewServer(NewServer(controls).Handler())
+	defer server.Close()
+
+	post := func(path string, body string) int {
+		resp, err := http.Post(server.URL+path, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("Failed to post %s: %v", path, err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	post("/rate", `{"rate": 75}`)
+	post("/rate", `{"delta": -10}`)
+	post("/pause", "")
+	post("/reset", "")
+	if status := post("/rate", `{"rate": 0}`); status != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for invalid rate, got %d", status)
+	}
+	if controls.rate != 75 || len(controls.changes) != 1 || controls.changes[0] != -10 || !controls.paused || controls.resets != 1 {
+		t.Errorf("Unexpected controls %+v", controls)
+	}
+	post("/resume", "")
+	if controls.paused {
+		t.Errorf("Expected resumed")
+	}
+
+	resp, err := http.Get(server.URL + "/pause")
+	if err != nil {
+		t.Fatalf("Failed to get: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405 for GET, got %d", resp.StatusCode)
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL+"/reset", nil)
+	req.Header.Set("Origin", "http://evil.example")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to post: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden || controls.resets != 1 {
+		t.Errorf("Expected cross-origin reset to be rejected, got %d", resp.StatusCode)
+	}
+}
+
+func TestServeDone(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	s := NewServer(&testControls{})
+	s.Serve(listener)
+	_ = listener.Close()
+	select {
+	case <-s.Done():
+	case <-time.After(2 * time.Second):
+		t.Errorf("Expected done after the listener is closed")
+	}
+}
+
+func TestEvents(t *testing.T) {
+	s := NewServer(&testControls{})
+	s.Publish(Sample{Time: 1, Requests: 10})
+	server := httptest.NewServer(s.Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/events")
+	if err != nil {
+		t.Fatalf("Failed to get events: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Errorf("Unexpected content type %q", resp.Header.Get("Content-Type"))
+	}
+
+	samples := make(chan Sample)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+				var sample Sample
+				_ = json.Unmarshal([]byte(data), &sample)
+				samples <- sample
+			}
+		}
+	}()
+	receive := func() Sample {
+		select {
+		case sample := <-samples:
+			return sample
+		case <-time.After(2 * time.Second):
+			t.Fatalf("No sample received")
+		}
+		return Sample{}
+	}
+
+	if sample := receive(); sample.Time != 1 || sample.Requests != 10 {
+		t.Errorf("Expected history sample, got %+v", sample)
+	}
+	// wait until the browser is subscribed
+	for {
+		s.mu.Lock()
+		n := len(s.clients)
+		s.mu.Unlock()
+		if n > 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	s.Publish(Sample{Time: 2, Paused: true})
+	if sample := receive(); sample.Time != 2 || !sample.Paused {
+		t.Errorf("Expected live sample, got %+v", sample)
+	}
+}
@@ -0,0 +1,87 @@
+package slapperx
+
+import (
+	"github.com/s-macke/slapperx/src/chart"
+	"time"
+)
+
+const (
+	zoomFactor      = 2   // range change of one zoom step
+	panFraction     = 0.5 // shift of one pan step as fraction of the range
+	autoFitInterval = 5 * time.Second
+)
+
+// rebucket changes the Y axis with update and migrates the histogram and heatmap.
+// The screen is cleared if the number of buckets changes.
+func (ui *UI) rebucket(update func()) {
+	buckets := ui.lbc.buckets
+	stats.timings.Rebucket(ui.lbc, update)
+	if ui.lbc.buckets != buckets {
+		ui.clearScreen()
+	}
+}
+
+// Zoom multiplies the latency range of the Y axis by factor and turns off the auto-fit
+func (ui *UI) Zoom(factor float64) {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+	ui.autoFit = false
+	ui.rebucket(func() { ui.lbc.zoom(factor) })
+}
+
+// Pan shifts the latency range of the Y axis by a fraction of its width and turns off the auto-fit
+func (ui *UI) Pan(fraction float64) {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+	ui.autoFit = false
+	ui.rebucket(func() { ui.lbc.pan(fraction) })
+}
+
+// CycleScale switches between the log, linear and custom scale
+func (ui *UI) CycleScale() {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+	next := (ui.lbc.scale + 1) % scaleMode(len(scaleNames))
+	ui.rebucket(func() { ui.lbc.setScale(next, ui.plotHeight) })
+}
+
+// ToggleAutoFit turns on or off the periodic fit of the latency range to the observed latencies
+func (ui *UI) ToggleAutoFit() {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+	ui.autoFit = !ui.autoFit
+}
+
+// fitRange fits the latency range to the requests finished since the last fit if auto-fit is on.
+// The range starts at the minimum and ends at the p99 latency, rounded to 1, 2 or 5 times a power of ten.
+func (ui *UI) fitRange() {
+	overall := ui.fitInterval.Take().Overall
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+	if !ui.autoFit || overall.Requests == 0 {
+		return
+	}
+	minY, maxY := chart.NiceMin(overall.Latency.MinMs), chart.NiceMax(overall.Latency.P99Ms)
+	if minY == ui.lbc.minY && maxY == ui.lbc.maxY {
+		return
+	}
+	ui.rebucket(func() { ui.lbc.setRange(minY, maxY) })
+}
+
+// startAutoFit fits the latency range periodically until the UI is closed
+func (ui *UI) startAutoFit() {
+	ui.wg.Add(1)
+	go func() {
+		defer ui.wg.Done()
+		ticker := time.NewTicker(autoFitInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				ui.fitRange()
+			case <-ui.done:
+				return
+			}
+		}
+	}()
+}